@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+	"wwfc/database"
+	"wwfc/gpcm"
+	"wwfc/logging"
+)
+
+// Longer messages don't fit on the in-game message box
+const maxAnnouncementLength = 400
+
+// Sent announcements stay listed for this long
+const announcementHistory = 7 * 24 * time.Hour
+
+type v1AnnouncementRequest struct {
+	Message string     `json:"message"`
+	Game    string     `json:"game"`
+	PID     uint32     `json:"pid"`
+	SendAt  *time.Time `json:"send_at"`
+}
+
+type v1AnnouncementReply struct {
+	AnnouncementId int64 `json:"id"`
+	Scheduled      bool  `json:"scheduled"`
+	Recipients     int   `json:"recipients"`
+}
+
+func handleV1Announcements(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		handleV1ListAnnouncements(w, r)
+	case http.MethodPost:
+		handleV1CreateAnnouncement(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, OPTIONS")
+		replyV1Error(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
+	}
+}
+
+func handleV1ListAnnouncements(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateV1(w, r, "announce"); !ok {
+		return
+	}
+
+	list, err := announcements.GetAnnouncements(ctx, time.Now().Add(-announcementHistory))
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch announcements")
+		return
+	}
+
+	replyJSON(w, http.StatusOK, list)
+}
+
+func handleV1CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := authenticateV1(w, r, "announce")
+	if !ok {
+		return
+	}
+
+	var request v1AnnouncementRequest
+	if !decodeV1Body(w, r, &request) {
+		return
+	}
+
+	if request.Message == "" {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Missing message")
+		return
+	}
+
+	if utf8.RuneCountInString(request.Message) > maxAnnouncementLength {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Message is longer than "+strconv.Itoa(maxAnnouncementLength)+" characters")
+		return
+	}
+
+	sendAt := time.Now()
+	scheduled := request.SendAt != nil && request.SendAt.After(sendAt)
+	if scheduled {
+		// Timestamps are stored in the server's local time
+		sendAt = request.SendAt.Local()
+	}
+
+	announcementId, err := announcements.AddAnnouncement(ctx, request.Message, request.Game, request.PID, sendAt, moderator.Name)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to save announcement")
+		return
+	}
+
+	recordAudit(r, moderator, "announce", request.PID, map[string]string{
+		"id":      strconv.FormatInt(announcementId, 10),
+		"game":    request.Game,
+		"message": request.Message,
+		"send_at": sendAt.UTC().Format(time.RFC3339),
+	})
+
+	reply := v1AnnouncementReply{AnnouncementId: announcementId, Scheduled: scheduled}
+	if !scheduled {
+		recipients, sent := gpcm.SendDueAnnouncements()[announcementId]
+		if !sent {
+			// The scheduled announcement check claimed it first, and has recorded the count by now
+			recipients = getAnnouncementRecipients(announcementId, sendAt)
+		}
+		reply.Recipients = recipients
+	}
+
+	replyJSON(w, http.StatusCreated, reply)
+}
+
+// getAnnouncementRecipients looks up the recorded recipient count of an announcement sent after sendAt
+func getAnnouncementRecipients(announcementId int64, sendAt time.Time) int {
+	list, err := announcements.GetAnnouncements(ctx, sendAt.Add(-time.Second))
+	if err != nil {
+		logging.Error("API", "Failed to fetch announcement recipients:", err)
+		return 0
+	}
+
+	for _, announcement := range list {
+		if announcement.AnnouncementId == announcementId && announcement.Recipients != nil {
+			return *announcement.Recipients
+		}
+	}
+
+	return 0
+}
+
+func handleV1CancelAnnouncement(w http.ResponseWriter, r *http.Request, idStr string) {
+	moderator, ok := authenticateV1(w, r, "announce")
+	if !ok {
+		return
+	}
+
+	announcementId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || announcementId <= 0 {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Invalid announcement id")
+		return
+	}
+
+	switch err := announcements.CancelAnnouncement(ctx, announcementId); err {
+	case nil:
+	case database.ErrAnnouncementNotFound:
+		replyV1Error(w, http.StatusNotFound, "not_found", "Announcement does not exist")
+		return
+	case database.ErrAnnouncementSent:
+		replyV1Error(w, http.StatusConflict, "conflict", "Announcement has already been sent")
+		return
+	default:
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to cancel announcement")
+		return
+	}
+
+	recordAudit(r, moderator, "announce_cancel", 0, map[string]string{"id": idStr})
+	replyJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
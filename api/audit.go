@@ -0,0 +1,173 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+const (
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// recordAudit writes a successful moderation action to the audit log
+func recordAudit(r *http.Request, moderator database.Moderator, action string, profileId uint32, params map[string]string) {
+	ipAddress, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ipAddress = r.RemoteAddr
+	}
+
+	err = auditLog.AddAuditEntry(ctx, moderator.Name, action, profileId, params, ipAddress)
+	if err != nil {
+		logging.Error("API", "Failed to write audit log entry for", aurora.Cyan(action), "by", aurora.Cyan(moderator.Name), "-", err)
+	}
+}
+
+func HandleAudit(w http.ResponseWriter, r *http.Request) {
+	entries, format, errorString := handleAuditImpl(w, r)
+
+	var data []byte
+	if errorString != "" {
+		data, _ = json.Marshal(map[string]string{"error": errorString})
+		w.Header().Set("Content-Type", "application/json")
+	} else if format == "csv" {
+		data = auditToCSV(entries)
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+	} else {
+		data, _ = json.Marshal(entries)
+		w.Header().Set("Content-Type", "application/json")
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
+
+func handleAuditImpl(w http.ResponseWriter, r *http.Request) ([]database.AuditEntry, string, string) {
+	if _, errorString := authenticate(r, "audit"); errorString != "" {
+		return nil, "", errorString
+	}
+
+	u, err := url.Parse(r.URL.String())
+	if err != nil {
+		return nil, "", "Bad request"
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, "", "Bad request"
+	}
+
+	filter := database.AuditFilter{
+		Moderator: query.Get("moderator"),
+		Limit:     auditDefaultLimit,
+	}
+
+	if pidStr := query.Get("pid"); pidStr != "" {
+		pid, err := strconv.ParseUint(pidStr, 10, 32)
+		if err != nil {
+			return nil, "", "Invalid pid"
+		}
+
+		filter.ProfileId = uint32(pid)
+	}
+
+	// Time range as unix timestamps
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := strconv.ParseInt(fromStr, 10, 64)
+		if err != nil {
+			return nil, "", "Invalid from"
+		}
+
+		after := time.Unix(from, 0)
+		filter.After = &after
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := strconv.ParseInt(toStr, 10, 64)
+		if err != nil {
+			return nil, "", "Invalid to"
+		}
+
+		before := time.Unix(to, 0)
+		filter.Before = &before
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit < 1 || filter.Limit > auditMaxLimit {
+			return nil, "", "Invalid limit"
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		filter.Offset, err = strconv.Atoi(offsetStr)
+		if err != nil || filter.Offset < 0 {
+			return nil, "", "Invalid offset"
+		}
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		return nil, "", "Invalid format"
+	}
+
+	entries, err := auditLog.GetAuditLog(ctx, filter)
+	if err != nil {
+		logging.Error("API", "Failed to fetch audit log:", err)
+		return nil, "", "Failed to fetch audit log"
+	}
+
+	return entries, format, ""
+}
+
+func auditToCSV(entries []database.AuditEntry) []byte {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Write([]string{"id", "created", "moderator", "action", "pid", "ip", "params"})
+
+	for _, entry := range entries {
+		pid := ""
+		if entry.ProfileId != 0 {
+			pid = strconv.FormatUint(uint64(entry.ProfileId), 10)
+		}
+
+		// Flatten the parameters into key=value pairs in a stable order
+		keys := make([]string, 0, len(entry.Params))
+		for key := range entry.Params {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		params := make([]string, 0, len(keys))
+		for _, key := range keys {
+			params = append(params, key+"="+entry.Params[key])
+		}
+
+		writer.Write([]string{
+			strconv.FormatInt(entry.EntryId, 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.Moderator,
+			entry.Action,
+			pid,
+			entry.IpAddress,
+			strings.Join(params, ";"),
+		})
+	}
+
+	writer.Flush()
+	return buffer.Bytes()
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+var (
+	rolesViewer    = []string{database.RoleViewer, database.RoleModerator, database.RoleAdmin}
+	rolesModerator = []string{database.RoleModerator, database.RoleAdmin}
+	rolesAdmin     = []string{database.RoleAdmin}
+)
+
+const (
+	errorMissingToken       = "Missing API token"
+	errorInvalidToken       = "Invalid API token"
+	errorAuthFailed         = "Failed to authenticate"
+	errorInsufficientAccess = "Insufficient permissions"
+)
+
+// Roles allowed to use each authenticated endpoint
+var endpointPermissions = map[string][]string{
+	"ban":           rolesModerator,
+	"unban":         rolesModerator,
+	"kick":          rolesModerator,
+	"ban_history":   rolesViewer,
+	"ban_expiry":    rolesModerator,
+	"ban_appeal":    rolesModerator,
+	"trusted_fetch": rolesViewer,
+	"trusted_edit":  rolesModerator,
+	"audit":         rolesModerator,
+	"player":        rolesViewer,
+	"announce":      rolesModerator,
+	"motd":          rolesModerator,
+	"links":         rolesModerator,
+	"reload":        rolesAdmin,
+	"moderators":    rolesAdmin,
+}
+
+// authenticate checks the API token in the Authorization header against the endpoint's permissions.
+// Returns the moderator on success, or an error string otherwise.
+func authenticate(r *http.Request, endpoint string) (database.Moderator, string) {
+	header := r.Header.Get("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return database.Moderator{}, errorMissingToken
+	}
+
+	moderator, err := moderators.AuthenticateModerator(ctx, token)
+	if err == database.ErrModeratorNotFound {
+		logging.Warn("API", "Invalid API token from", aurora.BrightCyan(r.RemoteAddr))
+		return database.Moderator{}, errorInvalidToken
+	} else if err != nil {
+		logging.Error("API", "Failed to authenticate moderator:", err)
+		return database.Moderator{}, errorAuthFailed
+	}
+
+	for _, role := range endpointPermissions[endpoint] {
+		if role == moderator.Role {
+			return moderator, ""
+		}
+	}
+
+	logging.Warn("API", "Moderator", aurora.Cyan(moderator.Name), "with role", aurora.Cyan(moderator.Role), "is not allowed to use", aurora.Cyan(endpoint))
+	return database.Moderator{}, errorInsufficientAccess
+}
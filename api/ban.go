@@ -12,25 +12,16 @@ import (
 )
 
 func HandleBan(w http.ResponseWriter, r *http.Request) {
-	errorString := handleBanImpl(w, r)
-	if errorString != "" {
-		jsonData, _ := json.Marshal(map[string]string{"error": errorString})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	} else {
-		jsonData, _ := json.Marshal(map[string]string{"success": "true"})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	}
+	replyResult(w, handleBanImpl(w, r))
 }
 
 func handleBanImpl(w http.ResponseWriter, r *http.Request) string {
-	// TODO: Actual authentication rather than a fixed secret
-	// TODO: Use POST instead of GET
+	// Deprecated: use POST /api/v1/bans instead
+
+	moderator, errorString := authenticate(r, "ban")
+	if errorString != "" {
+		return errorString
+	}
 
 	u, err := url.Parse(r.URL.String())
 	if err != nil {
@@ -42,10 +33,6 @@ func handleBanImpl(w http.ResponseWriter, r *http.Request) string {
 		return "Bad request"
 	}
 
-	if apiSecret == "" || query.Get("secret") != apiSecret {
-		return "Invalid API secret"
-	}
-
 	pidStr := query.Get("pid")
 	if pidStr == "" {
 		return "Missing pid in request"
@@ -94,58 +81,73 @@ func handleBanImpl(w http.ResponseWriter, r *http.Request) string {
 	}
 
 	reason := query.Get("reason")
-	if "reason" == "" {
+	if reason == "" {
 		return "Missing ban reason"
 	}
 
 	// reason_hidden is optional
 	reasonHidden := query.Get("reason_hidden")
 
-	moderator := query.Get("moderator")
-	if "moderator" == "" {
-		moderator = "admin"
+	minutes, ok := banMinutes(days, hours, minutes)
+	if !ok {
+		return "Ban length is longer than 100 years"
 	}
 
-	minutes = days*24*60 + hours*60 + minutes
 	if minutes == 0 {
 		return "Missing ban length"
 	}
 
-	length := time.Duration(minutes) * time.Minute
-
-	if !database.BanUser(pool, ctx, uint32(pid), tos, length, reason, reasonHidden, moderator) {
+	if !banPlayer(r, moderator, uint32(pid), tos, minutes, reason, reasonHidden) {
 		return "Failed to ban user"
 	}
 
-	if tos {
-		gpcm.KickPlayer(uint32(pid), "banned")
-	} else {
-		gpcm.KickPlayer(uint32(pid), "restricted")
+	return ""
+}
+
+// maxBanMinutes caps ban lengths at 100 years, well below where the expiry time would overflow
+const maxBanMinutes = 100 * 365 * 24 * 60
+
+// banMinutes adds up a ban length, returning false if it is longer than maxBanMinutes
+func banMinutes(days uint64, hours uint64, minutes uint64) (uint64, bool) {
+	// Check each part first so the sum can't wrap around
+	if days > maxBanMinutes/(24*60) || hours > maxBanMinutes/60 || minutes > maxBanMinutes {
+		return 0, false
 	}
 
-	return ""
+	total := days*24*60 + hours*60 + minutes
+	return total, total <= maxBanMinutes
 }
 
-func HandleFetch(w http.ResponseWriter, r *http.Request) {
-	result := handleAddRemoveTrusted(w, r)
+// banPlayer issues the ban, kicks the player if they are online and records it in the audit log
+func banPlayer(r *http.Request, moderator database.Moderator, profileId uint32, tos bool, minutes uint64, reason string, reasonHidden string) bool {
+	length := time.Duration(minutes) * time.Minute
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if !bans.BanUser(ctx, profileId, tos, length, reason, reasonHidden, moderator.Name) {
+		return false
+	}
 
-	jsonResponse, err := json.Marshal(result)
-	if err != nil {
-		http.Error(w, "Error encoding JSON", http.StatusInternalServerError)
-		return
+	expires := time.Now().Add(length)
+	if tos {
+		gpcm.KickPlayerWithReason(profileId, "banned", reason, &expires)
+	} else {
+		gpcm.KickPlayerWithReason(profileId, "restricted", reason, &expires)
 	}
 
-	// Set Content-Length header
-	w.Header().Set("Content-Length", strconv.Itoa(len(jsonResponse)))
+	recordAudit(r, moderator, "ban", profileId, map[string]string{
+		"tos":           strconv.FormatBool(tos),
+		"minutes":       strconv.FormatUint(minutes, 10),
+		"reason":        reason,
+		"reason_hidden": reasonHidden,
+	})
+
+	return true
+}
 
-	w.Write(jsonResponse)
+func HandleFetch(w http.ResponseWriter, r *http.Request) {
+	replyJSON(w, http.StatusOK, handleAddRemoveTrusted(w, r))
 }
 
 func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{} {
-	// TODO: Actual authentication rather than a fixed secret
 	// TODO: Use POST instead of GET
 	var trusted bool
 	var pid32 uint32
@@ -159,19 +161,19 @@ func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{}
 		return map[string]string{"error": "Bad request"}
 	}
 
-	if query.Get("key") != apiSecret {
-		if query.Get("key") != apiTrusted {
-			return map[string]string{"error": "Invalid API secret"}
-		}
+	request := query.Get("type")
+	if request == "" {
+		return map[string]string{"error": "Missing Add or Remove or FETCH"}
 	}
-	if apiSecret == "" || apiTrusted == "" {
-		return map[string]string{"error": "Woops, haven't set up config"}
 
+	endpoint := "trusted_edit"
+	if request == "FETCH" {
+		endpoint = "trusted_fetch"
 	}
 
-	request := query.Get("type")
-	if "type" == "" {
-		return map[string]string{"error": "Missing Add or Remove or FETCH"}
+	moderator, errorString := authenticate(r, endpoint)
+	if errorString != "" {
+		return map[string]string{"error": errorString}
 	}
 	if request != "FETCH" {
 		pidStr := query.Get("pid")
@@ -186,7 +188,7 @@ func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{}
 
 		pid32 = uint32(pid)
 
-		trusted, err = database.DoesUserTrusted(pool, ctx, pid32)
+		trusted, err = users.DoesUserTrusted(ctx, pid32)
 		if err != nil {
 			return "An error occured"
 		}
@@ -195,7 +197,7 @@ func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{}
 
 	switch request {
 	case "FETCH":
-		trustedIDs, err := database.FetchTrusted(pool, ctx)
+		trustedIDs, err := users.FetchTrusted(ctx)
 		if err != nil {
 			return map[string]string{"error": "Error fetching trusted IDs"}
 		}
@@ -218,10 +220,11 @@ func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{}
 		return string(friendCodesJSON)
 	case "Add":
 		if !trusted {
-			_, err = database.AddTrusted(pool, ctx, pid32)
+			_, err = users.AddTrusted(ctx, pid32)
 			if err != nil {
 				return map[string]string{"error": "couldn't add user"}
 			}
+			recordAudit(r, moderator, "trusted_add", pid32, nil)
 			return map[string]string{"success": "User Added"}
 		}
 		if trusted {
@@ -232,7 +235,8 @@ func handleAddRemoveTrusted(w http.ResponseWriter, r *http.Request) interface{}
 
 	case "Remove":
 		if trusted {
-			database.RemoveTrusted(pool, ctx, pid32)
+			users.RemoveTrusted(ctx, pid32)
+			recordAudit(r, moderator, "trusted_remove", pid32, nil)
 			return map[string]string{"success": "User Removed"}
 		}
 
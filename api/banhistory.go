@@ -0,0 +1,153 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+	"wwfc/database"
+)
+
+func HandleBanHistory(w http.ResponseWriter, r *http.Request) {
+	history, errorString := handleBanHistoryImpl(w, r)
+	replyValue(w, history, errorString)
+}
+
+func handleBanHistoryImpl(w http.ResponseWriter, r *http.Request) ([]database.Ban, string) {
+	if _, errorString := authenticate(r, "ban_history"); errorString != "" {
+		return nil, errorString
+	}
+
+	u, err := url.Parse(r.URL.String())
+	if err != nil {
+		return nil, "Bad request"
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, "Bad request"
+	}
+
+	pidStr := query.Get("pid")
+	if pidStr == "" {
+		return nil, "Missing pid in request"
+	}
+
+	pid, err := strconv.ParseUint(pidStr, 10, 32)
+	if err != nil {
+		return nil, "Invalid pid"
+	}
+
+	history, err := bans.GetBanHistory(ctx, uint32(pid))
+	if err != nil {
+		return nil, "Failed to fetch ban history"
+	}
+
+	return history, ""
+}
+
+func HandleBanExpiry(w http.ResponseWriter, r *http.Request) {
+	replyResult(w, handleBanExpiryImpl(w, r))
+}
+
+func handleBanExpiryImpl(w http.ResponseWriter, r *http.Request) string {
+	moderator, errorString := authenticate(r, "ban_expiry")
+	if errorString != "" {
+		return errorString
+	}
+
+	u, err := url.Parse(r.URL.String())
+	if err != nil {
+		return "Bad request"
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "Bad request"
+	}
+
+	idStr := query.Get("id")
+	if idStr == "" {
+		return "Missing id in request"
+	}
+
+	banId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "Invalid id"
+	}
+
+	// Unix timestamp of the new expiry time
+	expiresStr := query.Get("expires")
+	if expiresStr == "" {
+		return "Missing expires in request"
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil || expires <= 0 {
+		return "Invalid expires"
+	}
+
+	profileId, err := bans.UpdateBanExpiry(ctx, banId, time.Unix(expires, 0))
+	if err == database.ErrBanNotFound {
+		return "Ban does not exist"
+	} else if err != nil {
+		return "Failed to update ban"
+	}
+
+	recordAudit(r, moderator, "ban_expiry", profileId, map[string]string{
+		"ban_id":  idStr,
+		"expires": expiresStr,
+	})
+
+	return ""
+}
+
+func HandleBanAppeal(w http.ResponseWriter, r *http.Request) {
+	replyResult(w, handleBanAppealImpl(w, r))
+}
+
+func handleBanAppealImpl(w http.ResponseWriter, r *http.Request) string {
+	moderator, errorString := authenticate(r, "ban_appeal")
+	if errorString != "" {
+		return errorString
+	}
+
+	u, err := url.Parse(r.URL.String())
+	if err != nil {
+		return "Bad request"
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return "Bad request"
+	}
+
+	idStr := query.Get("id")
+	if idStr == "" {
+		return "Missing id in request"
+	}
+
+	banId, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "Invalid id"
+	}
+
+	note := query.Get("note")
+	if note == "" {
+		return "Missing note in request"
+	}
+
+	profileId, err := bans.AddBanAppeal(ctx, banId, moderator.Name, note)
+	if err == database.ErrBanNotFound {
+		return "Ban does not exist"
+	} else if err != nil {
+		return "Failed to add appeal note"
+	}
+
+	recordAudit(r, moderator, "ban_appeal", profileId, map[string]string{
+		"ban_id": idStr,
+		"note":   note,
+	})
+
+	return ""
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"wwfc/gpcm"
+	"wwfc/logging"
+)
+
+func handleV1ReloadErrorMessages(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := authenticateV1(w, r, "reload")
+	if !ok {
+		return
+	}
+
+	count, err := gpcm.ReloadErrorMessages()
+	if err != nil {
+		logging.Error("API", "Failed to reload error messages:", err)
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to reload error messages: "+err.Error())
+		return
+	}
+
+	recordAudit(r, moderator, "error_messages_reload", 0, map[string]string{"languages": strconv.Itoa(count)})
+	replyJSON(w, http.StatusOK, map[string]int{"languages": count})
+}
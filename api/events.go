@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"wwfc/common"
+	"wwfc/events"
+)
+
+const (
+	eventsBufferSize        = 256
+	eventsHeartbeatInterval = 30 * time.Second
+)
+
+// HandleEvents streams room and player changes as server-sent events.
+// Clients should refetch /api/groups when they receive a "resync" event, as some events were dropped.
+func HandleEvents(w http.ResponseWriter, r *http.Request) {
+	u, err := url.Parse(r.URL.String())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	gameNames := query["game"]
+
+	subscriber := events.Subscribe(eventsBufferSize)
+	defer subscriber.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+
+		case event, ok := <-subscriber.C:
+			if !ok {
+				// Server is shutting down
+				return
+			}
+
+			if subscriber.Dropped() {
+				fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+			}
+
+			if len(gameNames) > 0 && event.Game != "" && !common.StringInSlice(event.Game, gameNames) {
+				continue
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
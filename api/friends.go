@@ -0,0 +1,121 @@
+package api
+
+import (
+	"net/http"
+	"time"
+	"wwfc/common"
+	"wwfc/database"
+	"wwfc/gpcm"
+)
+
+type v1Friend struct {
+	ProfileId    uint32     `json:"pid"`
+	FriendCode   string     `json:"fc"`
+	AddedAt      time.Time  `json:"added"`
+	AuthorizedAt *time.Time `json:"authorized"`
+	Mutual       bool       `json:"mutual"`
+	Online       bool       `json:"online"`
+	LastSeen     *time.Time `json:"last_seen"`
+	Status       string     `json:"status,omitempty"`
+}
+
+// handleV1Friends lists the buddy list stored for a player along with each friend's online status
+func handleV1Friends(w http.ResponseWriter, r *http.Request, pidStr string) {
+	if _, ok := authenticateV1(w, r, "player"); !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	user, exists := users.GetProfile(ctx, pid)
+	if !exists {
+		replyV1Error(w, http.StatusNotFound, "not_found", "Player does not exist")
+		return
+	}
+
+	friendList, err := friends.GetFriends(ctx, pid)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch friends")
+		return
+	}
+
+	friendIds := []uint32{}
+	for _, friendship := range friendList {
+		friendIds = append(friendIds, friendship.FriendId)
+	}
+
+	lastSeen, err := loginSessions.GetLastSeen(ctx, friendIds)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch last seen times")
+		return
+	}
+
+	reply := []v1Friend{}
+	for _, friendship := range friendList {
+		friend := v1Friend{
+			ProfileId:    friendship.FriendId,
+			AddedAt:      friendship.AddedAt,
+			AuthorizedAt: friendship.AuthorizedAt,
+			Mutual:       friendship.AuthorizedAt != nil,
+		}
+
+		// Friend codes are only valid within the player's own game
+		if len(user.GsbrCode) >= 4 {
+			friend.FriendCode = common.CalcFriendCodeString(friendship.FriendId, user.GsbrCode[:4])
+		}
+
+		if seen, ok := lastSeen[friendship.FriendId]; ok {
+			friend.LastSeen = &seen
+		}
+
+		if session, online := gpcm.GetSessionInfo(friendship.FriendId); online {
+			friend.Online = true
+			friend.Status = session.Status
+		}
+
+		reply = append(reply, friend)
+	}
+
+	replyJSON(w, http.StatusOK, reply)
+}
+
+type v1FriendRequests struct {
+	Incoming []database.FriendRequest `json:"incoming"`
+	Outgoing []database.FriendRequest `json:"outgoing"`
+}
+
+// handleV1FriendRequests lists the friend requests waiting to be delivered to or from a player
+func handleV1FriendRequests(w http.ResponseWriter, r *http.Request, pidStr string) {
+	if _, ok := authenticateV1(w, r, "player"); !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	requests, err := friends.GetFriendRequests(ctx, pid)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch friend requests")
+		return
+	}
+
+	reply := v1FriendRequests{
+		Incoming: []database.FriendRequest{},
+		Outgoing: []database.FriendRequest{},
+	}
+
+	for _, request := range requests {
+		if request.ToProfileId == pid {
+			reply.Incoming = append(reply.Incoming, request)
+		} else {
+			reply.Outgoing = append(reply.Outgoing, request)
+		}
+	}
+
+	replyJSON(w, http.StatusOK, reply)
+}
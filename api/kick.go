@@ -1,7 +1,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -9,25 +8,16 @@ import (
 )
 
 func HandleKick(w http.ResponseWriter, r *http.Request) {
-	errorString := handleKickImpl(w, r)
-	if errorString != "" {
-		jsonData, _ := json.Marshal(map[string]string{"error": errorString})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	} else {
-		jsonData, _ := json.Marshal(map[string]string{"success": "true"})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	}
+	replyResult(w, handleKickImpl(w, r))
 }
 
 func handleKickImpl(w http.ResponseWriter, r *http.Request) string {
-	// TODO: Actual authentication rather than a fixed secret
-	// TODO: Use POST instead of GET
+	// Deprecated: use POST /api/v1/players/{pid}/kick instead
+
+	moderator, errorString := authenticate(r, "kick")
+	if errorString != "" {
+		return errorString
+	}
 
 	u, err := url.Parse(r.URL.String())
 	if err != nil {
@@ -39,10 +29,6 @@ func handleKickImpl(w http.ResponseWriter, r *http.Request) string {
 		return "Bad request"
 	}
 
-	if apiSecret == "" || query.Get("secret") != apiSecret {
-		return "Invalid API secret"
-	}
-
 	pidStr := query.Get("pid")
 	if pidStr == "" {
 		return "Missing pid in request"
@@ -53,6 +39,16 @@ func handleKickImpl(w http.ResponseWriter, r *http.Request) string {
 		return "Invalid pid"
 	}
 
-	gpcm.KickPlayer(uint32(pid), "moderator_kick")
+	reason := query.Get("reason")
+	gpcm.KickPlayerWithReason(uint32(pid), "moderator_kick", reason, nil)
+	recordAudit(r, moderator, "kick", uint32(pid), kickAuditDetails(reason))
 	return ""
 }
+
+func kickAuditDetails(reason string) map[string]string {
+	if reason == "" {
+		return nil
+	}
+
+	return map[string]string{"reason": reason}
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"wwfc/database"
+	"wwfc/gpcm"
+)
+
+const (
+	linksDefaultDepth = 2
+	linksMaxDepth     = 4
+	// Shared addresses such as CGNAT can link thousands of profiles, so the graph is cut off
+	linksMaxNodes   = 100
+	linksMaxRecords = 1000
+)
+
+type v1LinkNode struct {
+	ProfileId  uint32    `json:"pid"`
+	Depth      int       `json:"depth"`
+	Names      []string  `json:"names"`
+	DeviceIds  []string  `json:"device_ids"`
+	IPs        []string  `json:"ips"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	Banned     bool      `json:"banned"`
+	BannedTOS  bool      `json:"banned_tos"`
+	BanEvasion bool      `json:"ban_evasion"`
+	BanIds     []int64   `json:"ban_ids"`
+}
+
+type v1LinkEdge struct {
+	From  uint32 `json:"from"`
+	To    uint32 `json:"to"`
+	Via   string `json:"via"`
+	Value string `json:"value"`
+}
+
+type v1LinkGraph struct {
+	ProfileId uint32       `json:"pid"`
+	Nodes     []v1LinkNode `json:"nodes"`
+	Edges     []v1LinkEdge `json:"edges"`
+	Truncated bool         `json:"truncated"`
+}
+
+// handleV1Links walks the login history outwards from a profile through shared device IDs and IP addresses
+func handleV1Links(w http.ResponseWriter, r *http.Request, pidStr string) {
+	if _, ok := authenticateV1(w, r, "links"); !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	depth := linksDefaultDepth
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		var err error
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 1 || depth > linksMaxDepth {
+			replyV1Error(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("depth must be between 1 and %d", linksMaxDepth))
+			return
+		}
+	}
+
+	if _, exists := users.GetProfile(ctx, pid); !exists {
+		replyV1Error(w, http.StatusNotFound, "not_found", "Player does not exist")
+		return
+	}
+
+	graph, err := buildLinkGraph(pid, depth)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to build the link graph")
+		return
+	}
+
+	replyJSON(w, http.StatusOK, graph)
+}
+
+func buildLinkGraph(pid uint32, depth int) (v1LinkGraph, error) {
+	graph := v1LinkGraph{ProfileId: pid, Nodes: []v1LinkNode{}, Edges: []v1LinkEdge{}}
+
+	depths := map[uint32]int{pid: 0}
+	records := map[uint32][]database.LoginRecord{}
+	seenDevices := map[uint32]bool{}
+	seenIPs := map[string]bool{}
+	edges := map[v1LinkEdge]bool{}
+
+	frontier := []uint32{pid}
+	for level := 1; level <= depth && len(frontier) != 0; level++ {
+		history, err := links.GetLoginHistory(ctx, frontier)
+		if err != nil {
+			return graph, err
+		}
+
+		// Remember which profiles in the frontier used each device and address
+		deviceOwners := map[uint32][]uint32{}
+		ipOwners := map[string][]uint32{}
+		for _, record := range history {
+			records[record.ProfileId] = append(records[record.ProfileId], record)
+
+			// Default and leaked keys are shared by unrelated players
+			if record.NgDeviceId != 0 && !gpcm.IsCommonDeviceId(record.NgDeviceId) {
+				deviceOwners[record.NgDeviceId] = append(deviceOwners[record.NgDeviceId], record.ProfileId)
+			}
+			if record.IpAddress != "" {
+				ipOwners[record.IpAddress] = append(ipOwners[record.IpAddress], record.ProfileId)
+			}
+		}
+
+		devices := []uint32{}
+		for device := range deviceOwners {
+			if !seenDevices[device] {
+				seenDevices[device] = true
+				devices = append(devices, device)
+			}
+		}
+		ips := []string{}
+		for ip := range ipOwners {
+			if !seenIPs[ip] {
+				seenIPs[ip] = true
+				ips = append(ips, ip)
+			}
+		}
+
+		if len(devices) == 0 && len(ips) == 0 {
+			break
+		}
+
+		linked, err := links.FindLinkedLogins(ctx, devices, ips, linksMaxRecords)
+		if err != nil {
+			return graph, err
+		}
+		if len(linked) == linksMaxRecords {
+			graph.Truncated = true
+		}
+
+		frontier = []uint32{}
+		for _, record := range linked {
+			if _, known := depths[record.ProfileId]; !known {
+				if len(depths) >= linksMaxNodes {
+					graph.Truncated = true
+					continue
+				}
+
+				depths[record.ProfileId] = level
+				frontier = append(frontier, record.ProfileId)
+			}
+
+			for _, owner := range deviceOwners[record.NgDeviceId] {
+				addLinkEdge(edges, owner, record.ProfileId, "device", fmt.Sprintf("%08x", record.NgDeviceId))
+			}
+			for _, owner := range ipOwners[record.IpAddress] {
+				addLinkEdge(edges, owner, record.ProfileId, "ip", record.IpAddress)
+			}
+		}
+	}
+
+	// Profiles found at the last level still need their own details
+	missing := []uint32{}
+	for profileId := range depths {
+		if _, ok := records[profileId]; !ok {
+			missing = append(missing, profileId)
+		}
+	}
+	if len(missing) != 0 {
+		history, err := links.GetLoginHistory(ctx, missing)
+		if err != nil {
+			return graph, err
+		}
+		for _, record := range history {
+			records[record.ProfileId] = append(records[record.ProfileId], record)
+		}
+	}
+
+	profileIds := []uint32{}
+	deviceIds := []uint32{}
+	for profileId := range depths {
+		profileIds = append(profileIds, profileId)
+		for _, record := range records[profileId] {
+			if record.NgDeviceId != 0 {
+				deviceIds = append(deviceIds, record.NgDeviceId)
+			}
+		}
+	}
+
+	activeBans, err := bans.GetActiveBans(ctx, profileIds, deviceIds)
+	if err != nil {
+		return graph, err
+	}
+
+	for _, profileId := range profileIds {
+		graph.Nodes = append(graph.Nodes, makeLinkNode(profileId, depths[profileId], records[profileId], activeBans))
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Depth != graph.Nodes[j].Depth {
+			return graph.Nodes[i].Depth < graph.Nodes[j].Depth
+		}
+		return graph.Nodes[i].ProfileId < graph.Nodes[j].ProfileId
+	})
+
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Via != b.Via {
+			return a.Via < b.Via
+		}
+		return a.Value < b.Value
+	})
+
+	return graph, nil
+}
+
+// addLinkEdge stores the undirected edge once, with the lower profile ID first
+func addLinkEdge(edges map[v1LinkEdge]bool, a uint32, b uint32, via string, value string) {
+	if a == b {
+		return
+	}
+	if a > b {
+		a, b = b, a
+	}
+
+	edges[v1LinkEdge{From: a, To: b, Via: via, Value: value}] = true
+}
+
+func makeLinkNode(profileId uint32, depth int, records []database.LoginRecord, activeBans []database.Ban) v1LinkNode {
+	node := v1LinkNode{ProfileId: profileId, Depth: depth, Names: []string{}, DeviceIds: []string{}, IPs: []string{}, BanIds: []int64{}}
+
+	names := map[string]bool{}
+	devices := map[uint32]bool{}
+	ips := map[string]bool{}
+	for _, record := range records {
+		if node.FirstSeen.IsZero() || record.FirstSeen.Before(node.FirstSeen) {
+			node.FirstSeen = record.FirstSeen
+		}
+		if record.LastSeen.After(node.LastSeen) {
+			node.LastSeen = record.LastSeen
+		}
+
+		if record.InGameName != "" && !names[record.InGameName] {
+			names[record.InGameName] = true
+			node.Names = append(node.Names, record.InGameName)
+		}
+		if record.NgDeviceId != 0 && !devices[record.NgDeviceId] {
+			devices[record.NgDeviceId] = true
+			node.DeviceIds = append(node.DeviceIds, fmt.Sprintf("%08x", record.NgDeviceId))
+		}
+		if record.IpAddress != "" && !ips[record.IpAddress] {
+			ips[record.IpAddress] = true
+			node.IPs = append(node.IPs, record.IpAddress)
+		}
+	}
+
+	for _, ban := range activeBans {
+		if ban.ProfileId == profileId {
+			node.Banned = true
+			node.BannedTOS = node.BannedTOS || ban.TOS
+			node.BanIds = append(node.BanIds, ban.BanId)
+		} else if ban.NgDeviceId != 0 && devices[ban.NgDeviceId] {
+			// A device banned on another profile has logged in to this one
+			node.BanEvasion = true
+			node.BanIds = append(node.BanIds, ban.BanId)
+		}
+	}
+
+	return node
+}
@@ -2,38 +2,44 @@ package api
 
 import (
 	"context"
-	"fmt"
-	"wwfc/common"
-
-	"github.com/jackc/pgx/v4/pgxpool"
+	"wwfc/database"
+	"wwfc/events"
 )
 
 var (
-	ctx        = context.Background()
-	pool       *pgxpool.Pool
-	apiSecret  string
-	apiTrusted string
+	ctx           = context.Background()
+	users         database.UserRepository
+	bans          database.BanRepository
+	moderators    database.ModeratorRepository
+	auditLog      database.AuditRepository
+	friends       database.FriendRepository
+	announcements database.AnnouncementRepository
+	motds         database.MotdRepository
+	links         database.LinkRepository
+	loginSessions database.LoginSessionRepository
 )
 
+// StartServer checks the API has been given its repositories, as the handlers use them without checking
 func StartServer(reload bool) {
-	// Get config
-	config := common.GetConfig()
-
-	apiSecret = config.APISecret
-	apiTrusted = config.TrustedKey
-
-	// Start SQL
-	dbString := fmt.Sprintf("postgres://%s:%s@%s/%s", config.Username, config.Password, config.DatabaseAddress, config.DatabaseName)
-	dbConf, err := pgxpool.ParseConfig(dbString)
-	if err != nil {
-		panic(err)
+	if users == nil || bans == nil || moderators == nil || auditLog == nil || friends == nil || announcements == nil || motds == nil || links == nil || loginSessions == nil {
+		panic("api: SetRepositories must be called before StartServer")
 	}
+}
 
-	pool, err = pgxpool.ConnectConfig(ctx, dbConf)
-	if err != nil {
-		panic(err)
-	}
+// SetRepositories sets the repositories used by the API handlers
+func SetRepositories(userRepository database.UserRepository, banRepository database.BanRepository, moderatorRepository database.ModeratorRepository, auditRepository database.AuditRepository, friendRepository database.FriendRepository, announcementRepository database.AnnouncementRepository, motdRepository database.MotdRepository, linkRepository database.LinkRepository, loginSessionRepository database.LoginSessionRepository) {
+	users = userRepository
+	bans = banRepository
+	moderators = moderatorRepository
+	auditLog = auditRepository
+	friends = friendRepository
+	announcements = announcementRepository
+	motds = motdRepository
+	links = linkRepository
+	loginSessions = loginSessionRepository
 }
 
 func Shutdown() {
+	// End any open event streams so the HTTP server can shut down
+	events.CloseAll()
 }
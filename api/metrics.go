@@ -0,0 +1,28 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"wwfc/common"
+	"wwfc/logging"
+	"wwfc/metrics"
+)
+
+// HandleMetrics exports the backend and frontend metrics in the Prometheus text format
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	var buffer bytes.Buffer
+	metrics.Write(&buffer)
+
+	frontendMetrics, err := common.GetFrontendMetrics()
+	if err != nil {
+		logging.Error("API", "Failed to fetch frontend metrics:", err)
+	} else {
+		io.WriteString(&buffer, frontendMetrics)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Header().Set("Content-Length", strconv.Itoa(buffer.Len()))
+	w.Write(buffer.Bytes())
+}
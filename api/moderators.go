@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+func HandleModerators(w http.ResponseWriter, r *http.Request) {
+	result, errorString := handleModeratorsImpl(w, r)
+	replyValue(w, result, errorString)
+}
+
+func handleModeratorsImpl(w http.ResponseWriter, r *http.Request) (interface{}, string) {
+	admin, errorString := authenticate(r, "moderators")
+	if errorString != "" {
+		return nil, errorString
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, "Bad request"
+	}
+
+	action := r.Form.Get("action")
+	if action == "" || action == "list" {
+		list, err := moderators.GetModerators(ctx)
+		if err != nil {
+			return nil, "Failed to fetch moderators"
+		}
+
+		return list, ""
+	}
+
+	// Changes are only taken from a POST body, so they can't be made by following a link
+	if r.Method != http.MethodPost {
+		return nil, "Moderator changes must use POST"
+	}
+
+	name := r.PostForm.Get("name")
+	if name == "" {
+		return nil, "Missing name in request"
+	}
+
+	role := r.PostForm.Get("role")
+
+	switch action {
+	case "add":
+		token, err := moderators.CreateModerator(ctx, name, role)
+		if err != nil {
+			return nil, moderatorErrorString(err)
+		}
+
+		logging.Notice("API", "Moderator", aurora.Cyan(admin.Name), "created", aurora.Cyan(role), aurora.Cyan(name))
+		recordAudit(r, admin, "moderator_add", 0, map[string]string{"name": name, "role": role})
+		return map[string]string{"token": token}, ""
+
+	case "role":
+		err := moderators.SetModeratorRole(ctx, name, role)
+		if err != nil {
+			return nil, moderatorErrorString(err)
+		}
+
+		logging.Notice("API", "Moderator", aurora.Cyan(admin.Name), "changed role of", aurora.Cyan(name), "to", aurora.Cyan(role))
+		recordAudit(r, admin, "moderator_role", 0, map[string]string{"name": name, "role": role})
+
+	case "reset":
+		token, err := moderators.ResetModeratorToken(ctx, name)
+		if err != nil {
+			return nil, moderatorErrorString(err)
+		}
+
+		logging.Notice("API", "Moderator", aurora.Cyan(admin.Name), "reset API token of", aurora.Cyan(name))
+		recordAudit(r, admin, "moderator_reset", 0, map[string]string{"name": name})
+		return map[string]string{"token": token}, ""
+
+	case "remove":
+		if name == admin.Name {
+			return nil, "Cannot remove your own account"
+		}
+
+		err := moderators.DeleteModerator(ctx, name)
+		if err != nil {
+			return nil, moderatorErrorString(err)
+		}
+
+		logging.Notice("API", "Moderator", aurora.Cyan(admin.Name), "removed", aurora.Cyan(name))
+		recordAudit(r, admin, "moderator_remove", 0, map[string]string{"name": name})
+
+	default:
+		return nil, "Invalid action"
+	}
+
+	return map[string]string{"success": "true"}, ""
+}
+
+func moderatorErrorString(err error) string {
+	switch err {
+	case database.ErrModeratorNotFound:
+		return "Moderator does not exist"
+	case database.ErrModeratorExists:
+		return "Moderator already exists"
+	case database.ErrInvalidRole:
+		return "Invalid role"
+	}
+
+	return "Failed to update moderator"
+}
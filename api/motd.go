@@ -0,0 +1,136 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wwfc/database"
+	"wwfc/gpcm"
+	"wwfc/logging"
+)
+
+type v1MotdRequest struct {
+	Message      string     `json:"message"`
+	Game         string     `json:"game"`
+	Region       *byte      `json:"region"`
+	Language     *byte      `json:"language"`
+	HostPlatform string     `json:"host_platform"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Priority     int        `json:"priority"`
+}
+
+func handleV1Motds(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		handleV1ListMotds(w, r)
+	case http.MethodPost:
+		handleV1CreateMotd(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, OPTIONS")
+		replyV1Error(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
+	}
+}
+
+func handleV1ListMotds(w http.ResponseWriter, r *http.Request) {
+	if _, ok := authenticateV1(w, r, "motd"); !ok {
+		return
+	}
+
+	list, err := motds.GetMotds(ctx)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch messages of the day")
+		return
+	}
+
+	replyJSON(w, http.StatusOK, list)
+}
+
+func handleV1CreateMotd(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := authenticateV1(w, r, "motd")
+	if !ok {
+		return
+	}
+
+	var request v1MotdRequest
+	if !decodeV1Body(w, r, &request) {
+		return
+	}
+
+	if request.Message == "" {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Missing message")
+		return
+	}
+
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "ends_at must be after starts_at")
+		return
+	}
+
+	// Timestamps are stored in the server's local time
+	if request.StartsAt != nil {
+		*request.StartsAt = request.StartsAt.Local()
+	}
+	if request.EndsAt != nil {
+		*request.EndsAt = request.EndsAt.Local()
+	}
+
+	motdId, err := motds.AddMotd(ctx, database.Motd{
+		Message:      request.Message,
+		GameName:     request.Game,
+		Region:       request.Region,
+		Language:     request.Language,
+		HostPlatform: request.HostPlatform,
+		StartsAt:     request.StartsAt,
+		EndsAt:       request.EndsAt,
+		Priority:     request.Priority,
+		CreatedBy:    moderator.Name,
+	})
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to save message of the day")
+		return
+	}
+
+	reloadMotds()
+	recordAudit(r, moderator, "motd_add", 0, map[string]string{
+		"id":      strconv.Itoa(motdId),
+		"game":    request.Game,
+		"message": request.Message,
+	})
+
+	replyJSON(w, http.StatusCreated, map[string]int{"id": motdId})
+}
+
+func handleV1DeleteMotd(w http.ResponseWriter, r *http.Request, idStr string) {
+	moderator, ok := authenticateV1(w, r, "motd")
+	if !ok {
+		return
+	}
+
+	motdId, err := strconv.Atoi(idStr)
+	if err != nil || motdId <= 0 {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Invalid message of the day id")
+		return
+	}
+
+	switch err := motds.DeleteMotd(ctx, motdId); err {
+	case nil:
+	case database.ErrMotdNotFound:
+		replyV1Error(w, http.StatusNotFound, "not_found", "Message of the day does not exist")
+		return
+	default:
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to delete message of the day")
+		return
+	}
+
+	reloadMotds()
+	recordAudit(r, moderator, "motd_remove", 0, map[string]string{"id": idStr})
+	replyJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// reloadMotds makes changes visible at the next login instead of after the next refresh
+func reloadMotds() {
+	if err := gpcm.ReloadMessagesOfTheDay(); err != nil {
+		logging.Error("API", "Failed to reload messages of the day:", err)
+	}
+}
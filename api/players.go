@@ -0,0 +1,224 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"wwfc/common"
+	"wwfc/database"
+	"wwfc/gpcm"
+	"wwfc/qr2"
+)
+
+type v1FriendCode struct {
+	ProfileId  uint32 `json:"pid"`
+	GameId     string `json:"game_id"`
+	FriendCode string `json:"fc"`
+}
+
+type v1Player struct {
+	ProfileId    uint32         `json:"pid"`
+	UserId       uint64         `json:"user_id"`
+	GsbrCode     string         `json:"gsbrcd"`
+	NgDeviceId   uint32         `json:"device_id"`
+	UniqueNick   string         `json:"unique_nick"`
+	FirstName    string         `json:"first_name"`
+	LastName     string         `json:"last_name"`
+	LastIP       string         `json:"last_ip"`
+	LastInGameSN string         `json:"last_ingamesn"`
+	OpenHost     bool           `json:"open_host"`
+	Trusted      bool           `json:"trusted"`
+	Banned       bool           `json:"banned"`
+	Bans         []database.Ban `json:"bans"`
+	FriendCodes  []v1FriendCode `json:"friend_codes"`
+	LastSeen     *time.Time     `json:"last_seen"`
+	Playtime     int64          `json:"playtime_seconds"`
+
+	Online bool                   `json:"online"`
+	GPCM   *gpcm.SessionInfo      `json:"gpcm,omitempty"`
+	QR2    *qr2.PlayerSessionInfo `json:"qr2,omitempty"`
+}
+
+func handleV1Player(w http.ResponseWriter, r *http.Request, pidStr string) {
+	if _, ok := authenticateV1(w, r, "player"); !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	user, exists := users.GetProfile(ctx, pid)
+	if !exists {
+		replyV1Error(w, http.StatusNotFound, "not_found", "Player does not exist")
+		return
+	}
+
+	replyV1Player(w, user)
+}
+
+// handleV1FriendCode looks up a player by any of their friend codes
+func handleV1FriendCode(w http.ResponseWriter, r *http.Request, fcStr string) {
+	if _, ok := authenticateV1(w, r, "player"); !ok {
+		return
+	}
+
+	candidates := common.FriendCodeProfileIds(fcStr)
+	if candidates == nil {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Invalid friend code")
+		return
+	}
+
+	for _, pid := range candidates {
+		user, exists := users.GetProfile(ctx, pid)
+		if !exists || len(user.GsbrCode) < 4 {
+			continue
+		}
+
+		// The checksum depends on the game, so the friend code is only valid for the profile's own game
+		fc := common.CalcFriendCodeString(pid, user.GsbrCode[:4])
+		if sameFriendCode(fc, fcStr) {
+			replyV1Player(w, user)
+			return
+		}
+	}
+
+	replyV1Error(w, http.StatusNotFound, "not_found", "No player has this friend code")
+}
+
+func sameFriendCode(formatted string, input string) bool {
+	digits := []byte{}
+	for i := 0; i < len(input); i++ {
+		if input[i] >= '0' && input[i] <= '9' {
+			digits = append(digits, input[i])
+		}
+	}
+
+	return len(digits) == 12 && formatted == string(digits[0:4])+"-"+string(digits[4:8])+"-"+string(digits[8:12])
+}
+
+func replyV1Player(w http.ResponseWriter, user database.User) {
+	trusted, err := users.DoesUserTrusted(ctx, user.ProfileId)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch trusted status")
+		return
+	}
+
+	history, err := bans.GetBanHistory(ctx, user.ProfileId)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch ban history")
+		return
+	}
+
+	profiles, err := users.GetUserProfiles(ctx, user.UserId)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch linked profiles")
+		return
+	}
+
+	player := v1Player{
+		ProfileId:    user.ProfileId,
+		UserId:       user.UserId,
+		GsbrCode:     user.GsbrCode,
+		NgDeviceId:   user.NgDeviceId,
+		UniqueNick:   user.UniqueNick,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		LastIP:       user.LastIPAddress,
+		LastInGameSN: user.LastInGameSN,
+		OpenHost:     user.OpenHost,
+		Trusted:      trusted,
+		Bans:         history,
+		FriendCodes:  []v1FriendCode{},
+	}
+
+	playtime, err := loginSessions.GetPlaytime(ctx, user.ProfileId)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch playtime")
+		return
+	}
+	player.Playtime = int64(playtime / time.Second)
+
+	lastSeen, err := loginSessions.GetLastSeen(ctx, []uint32{user.ProfileId})
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch last seen time")
+		return
+	}
+	if seen, ok := lastSeen[user.ProfileId]; ok {
+		player.LastSeen = &seen
+	}
+
+	// Ban times are stored without a time zone, so let the database decide which bans are still active
+	activeBans, err := bans.GetActiveBans(ctx, []uint32{user.ProfileId}, nil)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch active bans")
+		return
+	}
+	player.Banned = len(activeBans) != 0
+
+	// One friend code for each game played on the same console
+	for _, profile := range profiles {
+		if len(profile.GsbrCode) < 4 {
+			continue
+		}
+
+		gameId := profile.GsbrCode[:4]
+		player.FriendCodes = append(player.FriendCodes, v1FriendCode{
+			ProfileId:  profile.ProfileId,
+			GameId:     gameId,
+			FriendCode: common.CalcFriendCodeString(profile.ProfileId, gameId),
+		})
+	}
+
+	if session, online := gpcm.GetSessionInfo(user.ProfileId); online {
+		player.Online = true
+		player.GPCM = &session
+	}
+
+	if login, exists := qr2.GetPlayerSession(user.ProfileId); exists {
+		player.QR2 = &login
+	}
+
+	replyJSON(w, http.StatusOK, player)
+}
+
+const (
+	sessionsDefaultLimit = 50
+	sessionsMaxLimit     = 500
+)
+
+// handleV1Sessions lists a player's most recent GPCM logins and how each one ended
+func handleV1Sessions(w http.ResponseWriter, r *http.Request, pidStr string) {
+	if _, ok := authenticateV1(w, r, "player"); !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	limit := sessionsDefaultLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > sessionsMaxLimit {
+			replyV1Error(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and "+strconv.Itoa(sessionsMaxLimit))
+			return
+		}
+	}
+
+	if _, exists := users.GetProfile(ctx, pid); !exists {
+		replyV1Error(w, http.StatusNotFound, "not_found", "Player does not exist")
+		return
+	}
+
+	sessions, err := loginSessions.GetLoginSessions(ctx, pid, limit)
+	if err != nil {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to fetch login sessions")
+		return
+	}
+
+	replyJSON(w, http.StatusOK, sessions)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// replyJSON writes data as a JSON response with the headers shared by every API endpoint
+func replyJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		http.Error(w, "Error encoding JSON", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
+	w.WriteHeader(statusCode)
+	w.Write(jsonData)
+}
+
+// replyResult writes the legacy {"error": ...} or {"success": "true"} response
+func replyResult(w http.ResponseWriter, errorString string) {
+	if errorString != "" {
+		replyJSON(w, http.StatusOK, map[string]string{"error": errorString})
+	} else {
+		replyJSON(w, http.StatusOK, map[string]string{"success": "true"})
+	}
+}
+
+// replyValue writes the legacy {"error": ...} response, or the value itself on success
+func replyValue(w http.ResponseWriter, value interface{}, errorString string) {
+	if errorString != "" {
+		replyJSON(w, http.StatusOK, map[string]string{"error": errorString})
+	} else {
+		replyJSON(w, http.StatusOK, value)
+	}
+}
@@ -1,33 +1,22 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 	"net/url"
 	"strconv"
-	"wwfc/database"
 )
 
 func HandleUnban(w http.ResponseWriter, r *http.Request) {
-	errorString := handleUnbanImpl(w, r)
-	if errorString != "" {
-		jsonData, _ := json.Marshal(map[string]string{"error": errorString})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	} else {
-		jsonData, _ := json.Marshal(map[string]string{"success": "true"})
-		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Content-Length", strconv.Itoa(len(jsonData)))
-		w.Write(jsonData)
-	}
+	replyResult(w, handleUnbanImpl(w, r))
 }
 
 func handleUnbanImpl(w http.ResponseWriter, r *http.Request) string {
-	// TODO: Actual authentication rather than a fixed secret
-	// TODO: Use POST instead of GET
+	// Deprecated: use DELETE /api/v1/bans/{pid} instead
+
+	moderator, errorString := authenticate(r, "unban")
+	if errorString != "" {
+		return errorString
+	}
 
 	u, err := url.Parse(r.URL.String())
 	if err != nil {
@@ -39,10 +28,6 @@ func handleUnbanImpl(w http.ResponseWriter, r *http.Request) string {
 		return "Bad request"
 	}
 
-	if apiSecret == "" || query.Get("secret") != apiSecret {
-		return "Invalid API secret"
-	}
-
 	pidStr := query.Get("pid")
 	if pidStr == "" {
 		return "Missing pid in request"
@@ -53,6 +38,11 @@ func handleUnbanImpl(w http.ResponseWriter, r *http.Request) string {
 		return "Invalid pid"
 	}
 
-	database.UnbanUser(pool, ctx, uint32(pid))
+	if !bans.UnbanUser(ctx, uint32(pid), moderator.Name) {
+		return "Failed to unban user"
+	}
+
+	recordAudit(r, moderator, "unban", uint32(pid), nil)
+
 	return ""
 }
@@ -0,0 +1,278 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"wwfc/database"
+	"wwfc/gpcm"
+)
+
+//go:embed openapi.yaml
+var openAPIDocument []byte
+
+// Request bodies larger than this are rejected
+const v1MaxBodySize = 64 * 1024
+
+type v1Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type v1ErrorEnvelope struct {
+	Error v1Error `json:"error"`
+}
+
+type v1BanRequest struct {
+	PID          uint32 `json:"pid"`
+	TOS          bool   `json:"tos"`
+	Minutes      uint64 `json:"minutes"`
+	Hours        uint64 `json:"hours"`
+	Days         uint64 `json:"days"`
+	Reason       string `json:"reason"`
+	ReasonHidden string `json:"reason_hidden"`
+}
+
+type v1KickRequest struct {
+	Reason string `json:"reason"`
+}
+
+func replyV1Error(w http.ResponseWriter, statusCode int, code string, message string) {
+	replyJSON(w, statusCode, v1ErrorEnvelope{Error: v1Error{Code: code, Message: message}})
+}
+
+// HandleV1 routes every request under /api/v1
+func HandleV1(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1"), "/")
+	segments := strings.Split(path, "/")
+
+	switch {
+	case path == "openapi.yaml":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Length", strconv.Itoa(len(openAPIDocument)))
+		w.Write(openAPIDocument)
+
+	case path == "bans":
+		if allowMethod(w, r, http.MethodPost) {
+			handleV1Ban(w, r)
+		}
+
+	case path == "announcements":
+		handleV1Announcements(w, r)
+
+	case len(segments) == 2 && segments[0] == "announcements":
+		if allowMethod(w, r, http.MethodDelete) {
+			handleV1CancelAnnouncement(w, r, segments[1])
+		}
+
+	case path == "error-messages/reload":
+		if allowMethod(w, r, http.MethodPost) {
+			handleV1ReloadErrorMessages(w, r)
+		}
+
+	case path == "motds":
+		handleV1Motds(w, r)
+
+	case len(segments) == 2 && segments[0] == "motds":
+		if allowMethod(w, r, http.MethodDelete) {
+			handleV1DeleteMotd(w, r, segments[1])
+		}
+
+	case len(segments) == 2 && segments[0] == "bans":
+		if allowMethod(w, r, http.MethodDelete) {
+			handleV1Unban(w, r, segments[1])
+		}
+
+	case len(segments) == 2 && segments[0] == "players":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1Player(w, r, segments[1])
+		}
+
+	case len(segments) == 2 && segments[0] == "friend-codes":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1FriendCode(w, r, segments[1])
+		}
+
+	case len(segments) == 3 && segments[0] == "players" && segments[2] == "friends":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1Friends(w, r, segments[1])
+		}
+
+	case len(segments) == 3 && segments[0] == "players" && segments[2] == "friend-requests":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1FriendRequests(w, r, segments[1])
+		}
+
+	case len(segments) == 3 && segments[0] == "players" && segments[2] == "sessions":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1Sessions(w, r, segments[1])
+		}
+
+	case len(segments) == 3 && segments[0] == "players" && segments[2] == "links":
+		if allowMethod(w, r, http.MethodGet) {
+			handleV1Links(w, r, segments[1])
+		}
+
+	case len(segments) == 3 && segments[0] == "players" && segments[2] == "kick":
+		if allowMethod(w, r, http.MethodPost) {
+			handleV1Kick(w, r, segments[1])
+		}
+
+	default:
+		replyV1Error(w, http.StatusNotFound, "not_found", "Unknown endpoint")
+	}
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+
+	w.Header().Set("Allow", method+", OPTIONS")
+	replyV1Error(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
+	return false
+}
+
+// authenticateV1 is authenticate with the failure already written to the response
+func authenticateV1(w http.ResponseWriter, r *http.Request, endpoint string) (database.Moderator, bool) {
+	moderator, errorString := authenticate(r, endpoint)
+	switch errorString {
+	case "":
+		return moderator, true
+	case errorMissingToken, errorInvalidToken:
+		replyV1Error(w, http.StatusUnauthorized, "unauthorized", errorString)
+	case errorInsufficientAccess:
+		replyV1Error(w, http.StatusForbidden, "forbidden", errorString)
+	default:
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", errorString)
+	}
+
+	return database.Moderator{}, false
+}
+
+func parseV1PID(w http.ResponseWriter, pidStr string) (uint32, bool) {
+	pid, err := strconv.ParseUint(pidStr, 10, 32)
+	if err != nil || pid == 0 {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Invalid pid")
+		return 0, false
+	}
+
+	return uint32(pid), true
+}
+
+// decodeV1Body strictly decodes a JSON request body into v
+func decodeV1Body(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, v1MaxBodySize))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(v)
+	if err == nil && decoder.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("request body must contain a single JSON object")
+	}
+
+	if err != nil {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Invalid JSON body: "+err.Error())
+		return false
+	}
+
+	return true
+}
+
+func handleV1Ban(w http.ResponseWriter, r *http.Request) {
+	moderator, ok := authenticateV1(w, r, "ban")
+	if !ok {
+		return
+	}
+
+	var request v1BanRequest
+	if !decodeV1Body(w, r, &request) {
+		return
+	}
+
+	if request.PID == 0 {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Missing pid")
+		return
+	}
+
+	if request.Reason == "" {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Missing ban reason")
+		return
+	}
+
+	minutes, ok := banMinutes(request.Days, request.Hours, request.Minutes)
+	if !ok {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Ban length is longer than 100 years")
+		return
+	}
+
+	if minutes == 0 {
+		replyV1Error(w, http.StatusBadRequest, "invalid_request", "Missing ban length")
+		return
+	}
+
+	if !banPlayer(r, moderator, request.PID, request.TOS, minutes, request.Reason, request.ReasonHidden) {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to ban user")
+		return
+	}
+
+	replyJSON(w, http.StatusCreated, map[string]bool{"success": true})
+}
+
+func handleV1Unban(w http.ResponseWriter, r *http.Request, pidStr string) {
+	moderator, ok := authenticateV1(w, r, "unban")
+	if !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	if !bans.UnbanUser(ctx, pid, moderator.Name) {
+		replyV1Error(w, http.StatusInternalServerError, "internal_error", "Failed to unban user")
+		return
+	}
+
+	recordAudit(r, moderator, "unban", pid, nil)
+	replyJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func handleV1Kick(w http.ResponseWriter, r *http.Request, pidStr string) {
+	moderator, ok := authenticateV1(w, r, "kick")
+	if !ok {
+		return
+	}
+
+	pid, ok := parseV1PID(w, pidStr)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var request v1KickRequest
+	if r.ContentLength != 0 && !decodeV1Body(w, r, &request) {
+		return
+	}
+
+	gpcm.KickPlayerWithReason(pid, "moderator_kick", request.Reason, nil)
+	recordAudit(r, moderator, "kick", pid, kickAuditDetails(request.Reason))
+	replyJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -0,0 +1,257 @@
+// Package bridge implements the streaming protocol that carries client traffic between the frontend
+// and the backend. Frames for every connection are multiplexed over one TCP stream, each prefixed with
+// its length, and queued frames are written in batches.
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Frame types
+const (
+	// FrameConnect tells the backend about a new connection. Address is the client's address.
+	FrameConnect byte = iota + 1
+	// FramePacket carries data read from or to be written to a connection
+	FramePacket
+	// FrameClose tells the other side a connection has been, or should be, closed
+	FrameClose
+	// FrameDatagram carries a UDP datagram. Address is the client's address.
+	FrameDatagram
+	// FrameAck tells the frontend the backend has handled a frame. Data holds the handled frame's type.
+	FrameAck
+)
+
+const (
+	// ConnectionWindow is how many packets the frontend sends for one connection before waiting for acks
+	ConnectionWindow = 16
+	// DatagramWindow is how many datagrams the frontend sends for one UDP server before waiting for acks
+	DatagramWindow = 256
+
+	// MaxFrameSize limits the size of a frame's body
+	MaxFrameSize = 1 << 20
+
+	// Frames waiting to be written. Send blocks when this is full.
+	sendQueueSize = 4096
+	// Buffered frames are flushed once this many bytes are waiting, even if more are queued
+	maxBatchSize = 64 * 1024
+)
+
+// magic is written first on a bridge stream, to tell it apart from an RPC connection on the same listener
+var magic = []byte("WWFCBRDG")
+
+var (
+	ErrClosed        = errors.New("bridge closed")
+	ErrFrameTooLarge = errors.New("frame too large")
+	ErrInvalidFrame  = errors.New("invalid frame")
+)
+
+// Frame is a message for one connection or UDP server. Data must not be modified after it is sent.
+type Frame struct {
+	Type    byte
+	Server  string
+	Index   uint64
+	Address string
+	Data    []byte
+}
+
+// Conn is one end of a bridge stream. Send and Receive may be used concurrently, but Receive must
+// only be called from one goroutine.
+type Conn struct {
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+	queue  chan Frame
+	closed chan struct{}
+	once   sync.Once
+
+	// OnFlush, if set before any frame is sent, is called with the number of frames in each batch
+	OnFlush func(frames int)
+}
+
+// Dial connects to a listener that accepts bridge streams with Accept
+func Dial(address string) (*Conn, error) {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(magic); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return NewConn(conn), nil
+}
+
+// Accept checks whether conn is a bridge stream. If it isn't, the returned ReadWriteCloser reads
+// everything conn received, and can be handed to whatever else serves the listener.
+func Accept(conn net.Conn) (*Conn, io.ReadWriteCloser, error) {
+	reader := bufio.NewReader(conn)
+	header, err := reader.Peek(len(magic))
+	if err != nil && len(header) == 0 {
+		return nil, nil, err
+	}
+
+	if !bytes.Equal(header, magic) {
+		return nil, struct {
+			io.Reader
+			io.Writer
+			io.Closer
+		}{reader, conn, conn}, nil
+	}
+
+	reader.Discard(len(magic))
+	return newConn(conn, reader), nil, nil
+}
+
+// NewConn starts a bridge over an established stream
+func NewConn(conn io.ReadWriteCloser) *Conn {
+	return newConn(conn, bufio.NewReader(conn))
+}
+
+func newConn(conn io.ReadWriteCloser, reader *bufio.Reader) *Conn {
+	c := &Conn{
+		conn:   conn,
+		reader: reader,
+		queue:  make(chan Frame, sendQueueSize),
+		closed: make(chan struct{}),
+	}
+
+	go c.writeLoop()
+	return c
+}
+
+// Send queues a frame, blocking while the queue is full
+func (c *Conn) Send(frame Frame) error {
+	if len(frame.Server) > 255 || len(frame.Address) > 255 || 11+len(frame.Server)+len(frame.Address)+len(frame.Data) > MaxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.queue <- frame:
+		return nil
+	case <-c.closed:
+		return ErrClosed
+	}
+}
+
+// Receive reads the next frame
+func (c *Conn) Receive() (Frame, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(c.reader, length[:]); err != nil {
+		return Frame{}, err
+	}
+
+	size := binary.BigEndian.Uint32(length[:])
+	if size > MaxFrameSize {
+		return Frame{}, ErrFrameTooLarge
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, body); err != nil {
+		return Frame{}, err
+	}
+
+	return decodeFrame(body)
+}
+
+// Close closes the stream. Frames that haven't been written yet are discarded.
+func (c *Conn) Close() error {
+	err := ErrClosed
+	c.once.Do(func() {
+		close(c.closed)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+func (c *Conn) writeLoop() {
+	writer := bufio.NewWriterSize(c.conn, maxBatchSize)
+	buffer := []byte{}
+
+	for {
+		var frame Frame
+		select {
+		case frame = <-c.queue:
+		case <-c.closed:
+			return
+		}
+
+		// Write everything that's already queued, then flush once
+		frames := 0
+		for {
+			buffer = appendFrame(buffer[:0], frame)
+			if _, err := writer.Write(buffer); err != nil {
+				c.Close()
+				return
+			}
+
+			frames++
+			if len(c.queue) == 0 || writer.Buffered() >= maxBatchSize {
+				break
+			}
+			frame = <-c.queue
+		}
+
+		if err := writer.Flush(); err != nil {
+			c.Close()
+			return
+		}
+
+		if c.OnFlush != nil {
+			c.OnFlush(frames)
+		}
+	}
+}
+
+// A frame is a big endian uint32 body length followed by the body:
+// type (1), server length (1), server, index (8), address length (1), address, data
+func appendFrame(buffer []byte, frame Frame) []byte {
+	size := 1 + 1 + len(frame.Server) + 8 + 1 + len(frame.Address) + len(frame.Data)
+
+	buffer = binary.BigEndian.AppendUint32(buffer, uint32(size))
+	buffer = append(buffer, frame.Type, byte(len(frame.Server)))
+	buffer = append(buffer, frame.Server...)
+	buffer = binary.BigEndian.AppendUint64(buffer, frame.Index)
+	buffer = append(buffer, byte(len(frame.Address)))
+	buffer = append(buffer, frame.Address...)
+	return append(buffer, frame.Data...)
+}
+
+func decodeFrame(body []byte) (Frame, error) {
+	if len(body) < 2 {
+		return Frame{}, ErrInvalidFrame
+	}
+
+	frame := Frame{Type: body[0]}
+	serverLength := int(body[1])
+	body = body[2:]
+
+	if len(body) < serverLength+8+1 {
+		return Frame{}, ErrInvalidFrame
+	}
+
+	frame.Server = string(body[:serverLength])
+	frame.Index = binary.BigEndian.Uint64(body[serverLength:])
+	addressLength := int(body[serverLength+8])
+	body = body[serverLength+8+1:]
+
+	if len(body) < addressLength {
+		return Frame{}, ErrInvalidFrame
+	}
+
+	frame.Address = string(body[:addressLength])
+	frame.Data = body[addressLength:]
+	return frame, nil
+}
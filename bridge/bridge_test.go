@@ -0,0 +1,295 @@
+package bridge
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	left, right := net.Pipe()
+	sender := NewConn(left)
+	receiver := NewConn(right)
+	defer sender.Close()
+	defer receiver.Close()
+
+	frames := []Frame{
+		{Type: FrameConnect, Server: "gpcm", Index: 1, Address: "192.0.2.1:50000"},
+		{Type: FramePacket, Server: "gpcm", Index: 1, Data: []byte(`\ka\\final\`)},
+		{Type: FrameDatagram, Server: "qr2", Address: "192.0.2.1:50001", Data: []byte{0x03, 0x00}},
+		{Type: FrameClose, Server: "gpcm", Index: 1 << 40},
+	}
+
+	go func() {
+		for _, frame := range frames {
+			sender.Send(frame)
+		}
+	}()
+
+	for _, expected := range frames {
+		frame, err := receiver.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if frame.Type != expected.Type || frame.Server != expected.Server || frame.Index != expected.Index || frame.Address != expected.Address || !bytes.Equal(frame.Data, expected.Data) {
+			t.Errorf("got %+v, expected %+v", frame, expected)
+		}
+	}
+}
+
+func TestAccept(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, _ := Dial(listener.Addr().String())
+		conn.Send(Frame{Type: FramePacket, Server: "gpcm", Index: 7})
+
+		other, _ := net.Dial("tcp", listener.Addr().String())
+		other.Write([]byte("not a bridge"))
+	}()
+
+	conn, _ := listener.Accept()
+	bridgeConn, other, err := Accept(conn)
+	if err != nil || bridgeConn == nil || other != nil {
+		t.Fatal("bridge stream not recognised:", err)
+	}
+	if frame, err := bridgeConn.Receive(); err != nil || frame.Index != 7 {
+		t.Error("unexpected frame:", frame, err)
+	}
+
+	conn, _ = listener.Accept()
+	bridgeConn, other, err = Accept(conn)
+	if err != nil || bridgeConn != nil || other == nil {
+		t.Fatal("other stream recognised as a bridge:", err)
+	}
+
+	buffer := make([]byte, 12)
+	if n, _ := other.Read(buffer); string(buffer[:n]) != "not a bridge" {
+		t.Errorf("other stream lost data: %q", buffer[:n])
+	}
+}
+
+func TestServeOrdering(t *testing.T) {
+	left, right := net.Pipe()
+	frontend := NewConn(left)
+	backend := NewConn(right)
+	defer frontend.Close()
+	defer backend.Close()
+
+	const connections = 100
+
+	mutex := sync.Mutex{}
+	received := map[uint64][]byte{}
+	go Serve(backend, func(frame Frame) {
+		if frame.Type == FramePacket {
+			mutex.Lock()
+			received[frame.Index] = append(received[frame.Index], frame.Data[0])
+			mutex.Unlock()
+		}
+	})
+
+	go func() {
+		for i := 0; i < ConnectionWindow; i++ {
+			for index := uint64(1); index <= connections; index++ {
+				frontend.Send(Frame{Type: FramePacket, Server: "gpcm", Index: index, Data: []byte{byte(i)}})
+			}
+		}
+	}()
+
+	for acks := 0; acks < connections*ConnectionWindow; acks++ {
+		frame, err := frontend.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if frame.Type != FrameAck {
+			t.Fatal("expected an ack, got", frame.Type)
+		}
+	}
+
+	for index := uint64(1); index <= connections; index++ {
+		for i, value := range received[index] {
+			if value != byte(i) {
+				t.Fatalf("connection %d received packets out of order: %v", index, received[index])
+			}
+		}
+	}
+}
+
+// A typical GPCM status update
+var benchmarkPacket = []byte(`\status\1\sesskey\1234567\statstring\Online\locstring\gamespy://1.2.3.4:27901/?type=dwc&pid=600000000\final\`)
+
+// BenchmarkBridge measures request/response throughput for many concurrent connections over one bridge
+// stream, with the backend echoing each packet back as GPCM replies to a status update.
+func BenchmarkBridge(b *testing.B) {
+	for _, connections := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("connections=%d", connections), func(b *testing.B) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer listener.Close()
+
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				backend, _, _ := Accept(conn)
+				Serve(backend, func(frame Frame) {
+					if frame.Type == FramePacket {
+						backend.Send(Frame{Type: FramePacket, Server: frame.Server, Index: frame.Index, Data: frame.Data})
+					}
+				})
+			}()
+
+			frontend, err := Dial(listener.Addr().String())
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer frontend.Close()
+
+			replies := make([]chan []byte, connections)
+			for i := range replies {
+				replies[i] = make(chan []byte, ConnectionWindow)
+			}
+
+			go func() {
+				for {
+					frame, err := frontend.Receive()
+					if err != nil {
+						return
+					}
+					if frame.Type == FramePacket {
+						replies[frame.Index] <- frame.Data
+					}
+				}
+			}()
+
+			runBenchmark(b, connections, func(index int) {
+				frontend.Send(Frame{Type: FramePacket, Server: "gpcm", Index: uint64(index), Address: "192.0.2.1:50000", Data: benchmarkPacket})
+				<-replies[index]
+			})
+		})
+	}
+}
+
+// net/rpc only serves exported types
+type RPCPacket struct {
+	Index int
+	Data  []byte
+}
+
+type RPCBackend struct {
+	frontend *rpc.Client
+}
+
+func (s *RPCBackend) HandlePacket(args RPCPacket, _ *struct{}) error {
+	return s.frontend.Call("RPCFrontend.SendPacket", args, nil)
+}
+
+type RPCFrontend struct {
+	mutex   sync.Mutex
+	replies []chan []byte
+}
+
+func (s *RPCFrontend) SendPacket(args RPCPacket, _ *struct{}) error {
+	s.mutex.Lock()
+	s.replies[args.Index] <- args.Data
+	s.mutex.Unlock()
+	return nil
+}
+
+// BenchmarkNetRPC runs the same load as BenchmarkBridge over the net/rpc calls the bridge replaced:
+// a synchronous call per packet in each direction, with replies written under one mutex.
+func BenchmarkNetRPC(b *testing.B) {
+	for _, connections := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("connections=%d", connections), func(b *testing.B) {
+			frontend := &RPCFrontend{replies: make([]chan []byte, connections)}
+			for i := range frontend.replies {
+				frontend.replies[i] = make(chan []byte, 1)
+			}
+
+			frontendServer := rpc.NewServer()
+			frontendServer.Register(frontend)
+			frontendListener := listenRPC(b, frontendServer)
+			defer frontendListener.Close()
+
+			frontendClient, err := rpc.Dial("tcp", frontendListener.Addr().String())
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer frontendClient.Close()
+
+			backendServer := rpc.NewServer()
+			backendServer.Register(&RPCBackend{frontend: frontendClient})
+			backendListener := listenRPC(b, backendServer)
+			defer backendListener.Close()
+
+			backendClient, err := rpc.Dial("tcp", backendListener.Addr().String())
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer backendClient.Close()
+
+			runBenchmark(b, connections, func(index int) {
+				if err := backendClient.Call("RPCBackend.HandlePacket", RPCPacket{Index: index, Data: benchmarkPacket}, nil); err != nil {
+					panic(err)
+				}
+				<-frontend.replies[index]
+			})
+		})
+	}
+}
+
+func listenRPC(b *testing.B, server *rpc.Server) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeConn(conn)
+		}
+	}()
+
+	return listener
+}
+
+// runBenchmark splits b.N round trips between connections, each running on its own goroutine
+func runBenchmark(b *testing.B, connections int, roundTrip func(index int)) {
+	b.SetBytes(int64(len(benchmarkPacket) * 2))
+	b.ResetTimer()
+
+	wg := sync.WaitGroup{}
+	for index := 0; index < connections; index++ {
+		count := b.N / connections
+		if index < b.N%connections {
+			count++
+		}
+
+		wg.Add(1)
+		go func(index int, count int) {
+			defer wg.Done()
+			for i := 0; i < count; i++ {
+				roundTrip(index)
+			}
+		}(index, count)
+	}
+
+	wg.Wait()
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "packets/s")
+}
@@ -0,0 +1,77 @@
+package bridge
+
+// Serve reads frames from conn and calls handle for each of them until the stream closes. Frames for
+// the same connection, or datagrams for the same UDP server, are handled in order on their own
+// goroutine, so a slow connection doesn't hold up the others. Each frame is acknowledged once
+// handle returns.
+//
+// The frontend's windows bound how many frames a connection can have waiting, so reading never
+// blocks on a busy connection.
+func Serve(conn *Conn, handle func(frame Frame)) error {
+	type streamKey struct {
+		server string
+		index  uint64
+	}
+
+	streams := map[streamKey]chan Frame{}
+	datagrams := map[string]chan Frame{}
+
+	defer func() {
+		for _, frames := range streams {
+			close(frames)
+		}
+		for _, frames := range datagrams {
+			close(frames)
+		}
+	}()
+
+	for {
+		frame, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+
+		switch frame.Type {
+		case FrameConnect, FramePacket, FrameClose:
+			key := streamKey{frame.Server, frame.Index}
+
+			// Connections carried over from before a reload start with a packet rather than FrameConnect
+			frames := streams[key]
+			if frames == nil {
+				frames = make(chan Frame, ConnectionWindow+2)
+				streams[key] = frames
+				go serveStream(conn, frames, handle)
+			}
+
+			frames <- frame
+
+			if frame.Type == FrameClose {
+				close(frames)
+				delete(streams, key)
+			}
+
+		case FrameDatagram:
+			frames := datagrams[frame.Server]
+			if frames == nil {
+				frames = make(chan Frame, DatagramWindow)
+				datagrams[frame.Server] = frames
+				go serveStream(conn, frames, handle)
+			}
+
+			frames <- frame
+		}
+	}
+}
+
+func serveStream(conn *Conn, frames chan Frame, handle func(frame Frame)) {
+	for frame := range frames {
+		handle(frame)
+
+		conn.Send(Frame{
+			Type:   FrameAck,
+			Server: frame.Server,
+			Index:  frame.Index,
+			Data:   []byte{frame.Type},
+		})
+	}
+}
@@ -26,16 +26,6 @@ func generateRandom(n int) []byte {
 	return key
 }
 
-var (
-	authTokenKey   = generateRandom(16)
-	authTokenIV    = generateRandom(16)
-	authTokenMagic = generateRandom(14)
-
-	loginTicketKey   = generateRandom(16)
-	loginTicketIV    = generateRandom(16)
-	loginTicketMagic = generateRandom(4)
-)
-
 func appendString(blob []byte, value string, maxlen int) []byte {
 	if len([]byte(value)) < maxlen {
 		blob = append(blob, append([]byte(value), make([]byte, maxlen-len(value))...)...)
@@ -82,14 +72,15 @@ func MarshalNASAuthToken(gamecd string, userid uint64, gsbrcd string, cfc uint64
 	blob = append(blob, byte(min(len([]byte(ctgpver)), 15)))
 	blob = appendString(blob, ctgpver, 15)
 
-	blob = append(blob, authTokenMagic...)
+	keys := getCurrentTokenKeys()
+	blob = append(blob, keys.AuthTokenMagic...)
 
-	block, err := aes.NewCipher(authTokenKey)
+	block, err := aes.NewCipher(keys.AuthTokenKey)
 	if err != nil {
 		panic(err)
 	}
 
-	cipher.NewCBCEncrypter(block, authTokenIV).CryptBlocks(blob, blob)
+	cipher.NewCBCEncrypter(block, keys.AuthTokenIV).CryptBlocks(blob, blob)
 	return "NDS" + Base64DwcEncoding.EncodeToString(blob), challenge
 }
 
@@ -101,24 +92,34 @@ func UnmarshalNASAuthToken(token string) (gamecd string, issuetime time.Time, us
 		return
 	}
 
-	blob, err := Base64DwcEncoding.DecodeString(token[3:])
+	encrypted, err := Base64DwcEncoding.DecodeString(token[3:])
 	if err != nil {
 		return
 	}
 
-	if len(blob) != 0xA0 { // 0x90 {
+	if len(encrypted) != 0xA0 { // 0x90 {
 		err = errors.New("invalid auth token length")
 		return
 	}
 
-	block, err := aes.NewCipher(authTokenKey)
-	if err != nil {
-		panic(err)
-	}
+	// Tokens issued before the last key rotation are still accepted during the grace period
+	var blob []byte
+	for _, keys := range getTokenKeys() {
+		block, err := aes.NewCipher(keys.AuthTokenKey)
+		if err != nil {
+			panic(err)
+		}
+
+		decrypted := make([]byte, len(encrypted))
+		cipher.NewCBCDecrypter(block, keys.AuthTokenIV).CryptBlocks(decrypted, encrypted)
 
-	cipher.NewCBCDecrypter(block, authTokenIV).CryptBlocks(blob, blob)
+		if bytes.Equal(decrypted[0xA0-len(keys.AuthTokenMagic):0xA0], keys.AuthTokenMagic) { //[0x90-len(authTokenMagic):0x90], authTokenMagic) { //CTGP STUFF lol
+			blob = decrypted
+			break
+		}
+	}
 
-	if !bytes.Equal(blob[0xA0-len(authTokenMagic):0xA0], authTokenMagic) { //[0x90-len(authTokenMagic):0x90], authTokenMagic) { //CTGP STUFF lol
+	if blob == nil {
 		err = errors.New("invalid auth token magic")
 		return
 	}
@@ -143,38 +144,48 @@ func UnmarshalNASAuthToken(token string) (gamecd string, issuetime time.Time, us
 func MarshalGPCMLoginTicket(profileId uint32) string {
 	blob := binary.LittleEndian.AppendUint64([]byte{}, uint64(time.Now().Unix()))
 	blob = binary.LittleEndian.AppendUint32(blob, profileId)
-	blob = append(blob, loginTicketMagic...)
+	keys := getCurrentTokenKeys()
+	blob = append(blob, keys.LoginTicketMagic...)
 
-	block, err := aes.NewCipher(loginTicketKey)
+	block, err := aes.NewCipher(keys.LoginTicketKey)
 	if err != nil {
 		panic(err)
 	}
 
-	cipher.NewCBCEncrypter(block, loginTicketIV).CryptBlocks(blob, blob)
+	cipher.NewCBCEncrypter(block, keys.LoginTicketIV).CryptBlocks(blob, blob)
 	return Base64DwcEncoding.EncodeToString(blob)
 }
 
 func UnmarshalGPCMLoginTicket(ticket string) (profileId uint32, issuetime time.Time, err error) {
 	err = nil
 
-	blob, err := Base64DwcEncoding.DecodeString(ticket)
+	encrypted, err := Base64DwcEncoding.DecodeString(ticket)
 	if err != nil {
 		return
 	}
 
-	if len(blob) != 0x10 {
+	if len(encrypted) != 0x10 {
 		err = errors.New("invalid login ticket length")
 		return
 	}
 
-	block, err := aes.NewCipher(loginTicketKey)
-	if err != nil {
-		panic(err)
-	}
+	var blob []byte
+	for _, keys := range getTokenKeys() {
+		block, err := aes.NewCipher(keys.LoginTicketKey)
+		if err != nil {
+			panic(err)
+		}
+
+		decrypted := make([]byte, len(encrypted))
+		cipher.NewCBCDecrypter(block, keys.LoginTicketIV).CryptBlocks(decrypted, encrypted)
 
-	cipher.NewCBCDecrypter(block, loginTicketIV).CryptBlocks(blob, blob)
+		if bytes.Equal(decrypted[0xC:0x10], keys.LoginTicketMagic) {
+			blob = decrypted
+			break
+		}
+	}
 
-	if !bytes.Equal(blob[0xC:0x10], loginTicketMagic) {
+	if blob == nil {
 		err = errors.New("invalid login ticket magic")
 		return
 	}
@@ -11,6 +11,12 @@ type Config struct {
 	DatabaseAddress string `xml:"databaseAddress"`
 	DatabaseName    string `xml:"databaseName"`
 
+	DatabaseMaxConns          int32  `xml:"databaseMaxConns,omitempty"`
+	DatabaseStatementTimeout  int    `xml:"databaseStatementTimeout,omitempty"`
+	DatabaseSSLMode           string `xml:"databaseSslMode,omitempty"`
+	DatabaseSSLRootCert       string `xml:"databaseSslRootCert,omitempty"`
+	DatabaseHealthCheckPeriod int    `xml:"databaseHealthCheckPeriod,omitempty"`
+
 	DefaultAddress  string  `xml:"address"`
 	GameSpyAddress  *string `xml:"gsAddress,omitempty"`
 	NASAddress      *string `xml:"nasAddress,omitempty"`
@@ -27,8 +33,14 @@ type Config struct {
 	EnableHTTPSExploitWii *bool `xml:"enableHttpsExploitWii,omitempty"`
 	EnableHTTPSExploitDS  *bool `xml:"enableHttpsExploitDS,omitempty"`
 
-	LogLevel  *int   `xml:"logLevel"`
-	LogOutput string `xml:"logOutput"`
+	LogLevel          *int             `xml:"logLevel"`
+	LogOutput         string           `xml:"logOutput"`
+	LogFormat         string           `xml:"logFormat"`
+	LogModuleLevels   []LogModuleLevel `xml:"logModuleLevels>module"`
+	LogRotateSize     int64            `xml:"logRotateSize"`
+	LogRotateInterval int              `xml:"logRotateInterval"`
+	LogMaxFiles       int              `xml:"logMaxFiles"`
+	LogMaxAge         int              `xml:"logMaxAge"`
 
 	CertPath      string `xml:"certPath"`
 	KeyPath       string `xml:"keyPath"`
@@ -38,12 +50,30 @@ type Config struct {
 	WiiCertPathDS string `xml:"wiiCertDerPathDS"`
 	KeyPathDS     string `xml:"keyPathDS"`
 
-	APISecret string `xml:"apiSecret"`
-
 	AllowDefaultDolphinKeys bool `xml:"allowDefaultDolphinKeys"`
 
+	DeviceAuthPolicy       string                 `xml:"deviceAuthPolicy,omitempty"`
+	DeviceAuthGamePolicies []DeviceAuthGamePolicy `xml:"deviceAuthGamePolicies>game"`
+
+	FriendRequestExpiry int `xml:"friendRequestExpiry,omitempty"`
+
+	TokenKeyFile     string `xml:"tokenKeyFile,omitempty"`
+	TokenKeyRotation *int   `xml:"tokenKeyRotation,omitempty"`
+	TokenKeyGrace    int    `xml:"tokenKeyGrace,omitempty"`
+
 	ServerName string `xml:"serverName,omitempty"`
-	TrustedKey string `xml:"TrustedKey,omitempty"`
+}
+
+// LogModuleLevel overrides logLevel for one module, e.g. <module name="QR2">warn</module>
+type LogModuleLevel struct {
+	Name  string `xml:"name,attr"`
+	Level string `xml:",chardata"`
+}
+
+// DeviceAuthGamePolicy overrides deviceAuthPolicy for one game, e.g. <game name="mariokartwii">require</game>
+type DeviceAuthGamePolicy struct {
+	Name   string `xml:"name,attr"`
+	Policy string `xml:",chardata"`
 }
 
 func GetConfig() Config {
@@ -97,6 +127,31 @@ func GetConfig() Config {
 		config.LogOutput = "StdOutAndFile"
 	}
 
+	if config.FriendRequestExpiry == 0 {
+		config.FriendRequestExpiry = 30
+	}
+
+	if config.TokenKeyFile == "" {
+		config.TokenKeyFile = "state/token_keys.json"
+	}
+
+	if config.TokenKeyRotation == nil {
+		hours := 24
+		config.TokenKeyRotation = &hours
+	}
+
+	if config.TokenKeyGrace == 0 {
+		config.TokenKeyGrace = 24
+	}
+
+	if config.DeviceAuthPolicy == "" {
+		config.DeviceAuthPolicy = "allow"
+	}
+
+	if config.LogFormat == "" {
+		config.LogFormat = "text"
+	}
+
 	if config.FrontendAddress == "" {
 		config.FrontendAddress = "127.0.0.1:29998"
 	}
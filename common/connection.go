@@ -1,20 +1,20 @@
 package common
 
 import (
+	"errors"
 	"net/rpc"
 	"time"
+	"wwfc/bridge"
 	"wwfc/logging"
 )
 
-var rpcFrontend *rpc.Client
-
-type RPCFrontendPacket struct {
-	Server string
-	Index  uint64
-	Data   []byte
-}
+var (
+	// Control calls go over RPC, client traffic over the bridge
+	rpcFrontend    *rpc.Client
+	frontendBridge *bridge.Conn
+)
 
-// ConnectFrontend connects to the frontend RPC server
+// ConnectFrontend connects to the frontend RPC server and opens the bridge stream
 func ConnectFrontend() {
 	config := GetConfig()
 
@@ -30,34 +30,45 @@ func ConnectFrontend() {
 			<-time.After(200 * time.Millisecond)
 		}
 	}
+
+	frontendBridge, err = bridge.Dial(config.BackendFrontendAddress)
+	if err != nil {
+		panic(err)
+	}
 }
 
-// SendPacket is used by backend servers to send a packet to a connection
-func SendPacket(server string, index uint64, data []byte) error {
-	if rpcFrontend == nil {
+// ServeFrontend handles the frames the frontend sends over the bridge until it disconnects
+func ServeFrontend(handle func(frame bridge.Frame)) error {
+	if frontendBridge == nil {
 		ConnectFrontend()
 	}
 
-	err := rpcFrontend.Call("RPCFrontendPacket.SendPacket", RPCFrontendPacket{Server: server, Index: index, Data: data}, nil)
-	if err != nil {
-		logging.Error("COMMON", "Failed to send packet to frontend:", err)
-	}
-	return err
+	return bridge.Serve(frontendBridge, handle)
 }
 
-// CloseConnection is used by backend servers to close a connection
-func CloseConnection(server string, index uint64) error {
-	if rpcFrontend == nil {
+// sendToFrontend queues a frame for the frontend, blocking while the bridge is backed up
+func sendToFrontend(frame bridge.Frame) error {
+	if frontendBridge == nil {
 		ConnectFrontend()
 	}
 
-	err := rpcFrontend.Call("RPCFrontendPacket.CloseConnection", RPCFrontendPacket{Server: server, Index: index}, nil)
+	err := frontendBridge.Send(frame)
 	if err != nil {
-		logging.Error("COMMON", "Failed to close connection:", err)
+		logging.Error("COMMON", "Failed to send to frontend:", err)
 	}
 	return err
 }
 
+// SendPacket is used by backend servers to send a packet to a connection
+func SendPacket(server string, index uint64, data []byte) error {
+	return sendToFrontend(bridge.Frame{Type: bridge.FramePacket, Server: server, Index: index, Data: data})
+}
+
+// CloseConnection is used by backend servers to close a connection, after any packets already sent to it
+func CloseConnection(server string, index uint64) error {
+	return sendToFrontend(bridge.Frame{Type: bridge.FrameClose, Server: server, Index: index})
+}
+
 // Ready will notify the frontend that the backend is ready to accept connections
 func Ready() error {
 	if rpcFrontend == nil {
@@ -99,3 +110,15 @@ func VerifyState(stateUuid string) (bool, error) {
 	}
 	return valid, err
 }
+
+// GetFrontendMetrics returns the frontend's metrics in the Prometheus text format
+func GetFrontendMetrics() (string, error) {
+	// Don't block the scrape waiting on a frontend that may not be running
+	if rpcFrontend == nil {
+		return "", errors.New("not connected to the frontend")
+	}
+
+	var metrics string
+	err := rpcFrontend.Call("RPCFrontendPacket.GetMetrics", struct{}{}, &metrics)
+	return metrics, err
+}
@@ -4,6 +4,8 @@ import (
 	"crypto/md5"
 	"encoding/binary"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -106,3 +108,31 @@ func GetRawFriendCodeString(fc uint64, reverse bool) string {
 
 	return s[len(s)-12:len(s)-8] + "-" + s[len(s)-8:len(s)-4] + "-" + s[len(s)-4:]
 }
+
+// FriendCodeProfileIds returns the profile IDs a formatted or unformatted friend code could belong to.
+// Some games display the digits in reverse, so the caller has to check each candidate against the profile's game.
+func FriendCodeProfileIds(fcStr string) []uint32 {
+	digits := strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(fcStr), "-", ""), " ", "")
+	if len(digits) != 12 {
+		return nil
+	}
+
+	reversed := make([]byte, 12)
+	for i := 0; i < 12; i++ {
+		reversed[i] = digits[11-i]
+	}
+
+	var pids []uint32
+	for _, candidate := range []string{digits, string(reversed)} {
+		fc, err := strconv.ParseUint(candidate, 10, 64)
+		if err != nil {
+			return nil
+		}
+
+		if pid := uint32(fc); pid != 0 && !slices.Contains(pids, pid) {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFriendCodeProfileIds(t *testing.T) {
+	for _, gameId := range []string{"RMCJ", "ADAE"} {
+		pid := uint32(600000123)
+		fc := CalcFriendCodeString(pid, gameId)
+
+		if pids := FriendCodeProfileIds(fc); !slices.Contains(pids, pid) {
+			t.Errorf("%s friend code %s: got candidates %v, want %d", gameId, fc, pids, pid)
+		}
+	}
+
+	if pids := FriendCodeProfileIds("1234-5678"); pids != nil {
+		t.Errorf("short friend code: got candidates %v, want none", pids)
+	}
+}
@@ -15,6 +15,8 @@ type GameInfo struct {
 	GameStatsVersion int
 	GameStatsKey     string
 	Description      string
+	// Maximum number of friends the game's roster can hold, 0 if unknown
+	MaxFriends int
 }
 
 var (
@@ -62,6 +64,8 @@ func ReadGameList() {
 
 	reader := csv.NewReader(file)
 	reader.Comma = '\t'
+	// The roster size column is optional
+	reader.FieldsPerRecord = -1
 	csvList, err := reader.ReadAll()
 	if err != nil {
 		panic(err)
@@ -90,6 +94,15 @@ func ReadGameList() {
 			}
 		}
 
+		maxFriends := 0
+
+		if len(entry) > 6 && entry[6] != "" {
+			maxFriends, err = strconv.Atoi(entry[6])
+			if err != nil {
+				panic(err)
+			}
+		}
+
 		gameList = append(gameList, GameInfo{
 			GameID:           gameId,
 			Name:             entry[1],
@@ -97,6 +110,7 @@ func ReadGameList() {
 			GameStatsVersion: gameStatsVer,
 			GameStatsKey:     entry[5],
 			Description:      entry[0],
+			MaxFriends:       maxFriends,
 		})
 
 		// Create lookup tables
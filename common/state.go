@@ -0,0 +1,301 @@
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// StateSnapshotPath is where the backend keeps its state between reloads
+const StateSnapshotPath = "state/snapshot.gob"
+
+// Bump stateSnapshotVersion if the header or section layout changes. Changes to a module's own
+// data are versioned per section instead.
+const stateSnapshotVersion = 1
+
+var stateSnapshotMagic = []byte("WWFCSTATE")
+
+// StateMigration converts a section's gob data from the previous version to the version it's registered for
+type StateMigration func(data []byte) ([]byte, error)
+
+type stateSection struct {
+	version    int
+	newValue   func() interface{}
+	migrations map[int]StateMigration
+}
+
+type stateSnapshot struct {
+	Version  int
+	UUID     string
+	Created  time.Time
+	Sections map[string]stateSectionData
+}
+
+type stateSectionData struct {
+	Version int
+	Data    []byte
+}
+
+var (
+	stateMutex    sync.Mutex
+	stateSections = map[string]stateSection{}
+
+	// Sections saved during shutdown, and sections read from the snapshot at startup
+	savedState  = map[string]stateSectionData{}
+	loadedState = map[string]stateSectionData{}
+)
+
+// RegisterStateSection declares a section of the state snapshot. newValue returns a pointer for the section's
+// data to be decoded into, and migrations maps each version after the first to the function that upgrades
+// data from the version before it. Sections are registered from the module's init function.
+func RegisterStateSection(name string, version int, newValue func() interface{}, migrations map[int]StateMigration) {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	if _, exists := stateSections[name]; exists {
+		panic("state section registered twice: " + name)
+	}
+
+	stateSections[name] = stateSection{
+		version:    version,
+		newValue:   newValue,
+		migrations: migrations,
+	}
+}
+
+// SaveStateSection encodes a section to be written with the next snapshot
+func SaveStateSection(name string, value interface{}) error {
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	section, exists := stateSections[name]
+	if !exists {
+		return errors.New("unregistered state section: " + name)
+	}
+
+	buffer := bytes.Buffer{}
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		return err
+	}
+
+	savedState[name] = stateSectionData{Version: section.version, Data: buffer.Bytes()}
+	return nil
+}
+
+// LoadStateSection decodes a section read by ReadStateSnapshot into value
+func LoadStateSection(name string, value interface{}) error {
+	stateMutex.Lock()
+	data, exists := loadedState[name]
+	stateMutex.Unlock()
+
+	if !exists {
+		return errors.New("state section not loaded: " + name)
+	}
+
+	return decodeStateSection(data.Data, value)
+}
+
+// WriteStateSnapshot writes every saved section to path, tagged with the frontend's state UUID
+func WriteStateSnapshot(path string, uuid string) error {
+	stateMutex.Lock()
+	snapshot := stateSnapshot{
+		Version:  stateSnapshotVersion,
+		UUID:     uuid,
+		Created:  time.Now(),
+		Sections: savedState,
+	}
+
+	for name := range stateSections {
+		if _, exists := savedState[name]; !exists {
+			stateMutex.Unlock()
+			return errors.New("state section was not saved: " + name)
+		}
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.Write(stateSnapshotMagic)
+	err := gob.NewEncoder(&buffer).Encode(snapshot)
+	stateMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, buffer.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// ReadStateSnapshot reads the snapshot at path, migrates every section to its registered version and checks
+// that it decodes. Nothing is loaded if any of that fails, and the backend should start clean.
+func ReadStateSnapshot(path string, uuid string) error {
+	snapshot, err := readStateSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	if snapshot.UUID != uuid {
+		return errors.New("snapshot is from a different state UUID")
+	}
+
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	loaded := map[string]stateSectionData{}
+	for name, section := range stateSections {
+		data, exists := snapshot.Sections[name]
+		if !exists {
+			return errors.New("missing state section " + name)
+		}
+
+		data, err = section.migrate(name, data)
+		if err != nil {
+			return err
+		}
+
+		if err := decodeStateSection(data.Data, section.newValue()); err != nil {
+			return fmt.Errorf("invalid state section %s: %w", name, err)
+		}
+
+		loaded[name] = data
+	}
+
+	for name := range snapshot.Sections {
+		if _, exists := stateSections[name]; !exists {
+			logging.Warn("COMMON", "Ignoring unknown state section", aurora.Cyan(name))
+		}
+	}
+
+	loadedState = loaded
+	return nil
+}
+
+// StateSnapshotInfo describes a snapshot for `wwfc state inspect`
+type StateSnapshotInfo struct {
+	Version  int                         `json:"version"`
+	UUID     string                      `json:"uuid"`
+	Created  time.Time                   `json:"created"`
+	Sections map[string]StateSectionInfo `json:"sections"`
+}
+
+type StateSectionInfo struct {
+	Version int         `json:"version"`
+	Size    int         `json:"size"`
+	Data    interface{} `json:"data,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// InspectStateSnapshot decodes every section of the snapshot at path, migrating it first if needed.
+// Sections that fail are reported with an error rather than failing the whole snapshot.
+func InspectStateSnapshot(path string) (StateSnapshotInfo, error) {
+	snapshot, err := readStateSnapshot(path)
+	if err != nil {
+		return StateSnapshotInfo{}, err
+	}
+
+	info := StateSnapshotInfo{
+		Version:  snapshot.Version,
+		UUID:     snapshot.UUID,
+		Created:  snapshot.Created,
+		Sections: map[string]StateSectionInfo{},
+	}
+
+	stateMutex.Lock()
+	defer stateMutex.Unlock()
+
+	names := []string{}
+	for name := range snapshot.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := snapshot.Sections[name]
+		sectionInfo := StateSectionInfo{Version: data.Version, Size: len(data.Data)}
+
+		section, exists := stateSections[name]
+		if !exists {
+			sectionInfo.Error = "unknown section"
+		} else if data, err = section.migrate(name, data); err != nil {
+			sectionInfo.Error = err.Error()
+		} else {
+			value := section.newValue()
+			if err := decodeStateSection(data.Data, value); err != nil {
+				sectionInfo.Error = err.Error()
+			} else {
+				sectionInfo.Data = value
+			}
+		}
+
+		info.Sections[name] = sectionInfo
+	}
+
+	return info, nil
+}
+
+func readStateSnapshot(path string) (stateSnapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return stateSnapshot{}, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(stateSnapshotMagic))
+	if _, err := io.ReadFull(file, magic); err != nil || !bytes.Equal(magic, stateSnapshotMagic) {
+		return stateSnapshot{}, errors.New("not a state snapshot")
+	}
+
+	var snapshot stateSnapshot
+	if err := gob.NewDecoder(file).Decode(&snapshot); err != nil {
+		return stateSnapshot{}, err
+	}
+
+	if snapshot.Version != stateSnapshotVersion {
+		return stateSnapshot{}, fmt.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	return snapshot, nil
+}
+
+func (s stateSection) migrate(name string, data stateSectionData) (stateSectionData, error) {
+	if data.Version > s.version {
+		return data, fmt.Errorf("state section %s is version %d, newer than this build's %d", name, data.Version, s.version)
+	}
+
+	for data.Version < s.version {
+		migration := s.migrations[data.Version+1]
+		if migration == nil {
+			return data, fmt.Errorf("no migration for state section %s to version %d", name, data.Version+1)
+		}
+
+		migrated, err := migration(data.Data)
+		if err != nil {
+			return data, fmt.Errorf("failed to migrate state section %s to version %d: %w", name, data.Version+1, err)
+		}
+
+		data = stateSectionData{Version: data.Version + 1, Data: migrated}
+	}
+
+	return data, nil
+}
+
+func decodeStateSection(data []byte, value interface{}) (err error) {
+	// Don't let a corrupt section take down the backend
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while decoding: %v", r)
+		}
+	}()
+
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"bytes"
+	"encoding/gob"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func resetStateSections() {
+	stateSections = map[string]stateSection{}
+	savedState = map[string]stateSectionData{}
+	loadedState = map[string]stateSectionData{}
+}
+
+func TestStateSnapshotMigration(t *testing.T) {
+	resetStateSections()
+	defer resetStateSections()
+
+	path := filepath.Join(t.TempDir(), "snapshot.gob")
+
+	// Version 1 stored counts as strings
+	RegisterStateSection("counts", 1, func() interface{} { return &map[string]string{} }, nil)
+	if err := SaveStateSection("counts", map[string]string{"a": "1", "b": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStateSnapshot(path, "uuid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 2 stores them as ints
+	resetStateSections()
+	RegisterStateSection("counts", 2, func() interface{} { return &map[string]int{} }, map[int]StateMigration{
+		2: func(data []byte) ([]byte, error) {
+			var old map[string]string
+			if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&old); err != nil {
+				return nil, err
+			}
+
+			counts := map[string]int{}
+			for key, value := range old {
+				counts[key], _ = strconv.Atoi(value)
+			}
+
+			buffer := bytes.Buffer{}
+			err := gob.NewEncoder(&buffer).Encode(counts)
+			return buffer.Bytes(), err
+		},
+	})
+
+	if err := ReadStateSnapshot(path, "other"); err == nil {
+		t.Error("snapshot accepted with the wrong state UUID")
+	}
+
+	if err := ReadStateSnapshot(path, "uuid"); err != nil {
+		t.Fatal(err)
+	}
+
+	var counts map[string]int
+	if err := LoadStateSection("counts", &counts); err != nil {
+		t.Fatal(err)
+	}
+	if counts["a"] != 1 || counts["b"] != 2 {
+		t.Errorf("unexpected migrated data: %v", counts)
+	}
+}
+
+func TestStateSnapshotInvalid(t *testing.T) {
+	resetStateSections()
+	defer resetStateSections()
+
+	path := filepath.Join(t.TempDir(), "snapshot.gob")
+
+	RegisterStateSection("names", 1, func() interface{} { return &map[string]string{} }, nil)
+	if err := SaveStateSection("names", map[string]string{"a": "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStateSnapshot(path, "uuid"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The type changed without a version bump
+	resetStateSections()
+	RegisterStateSection("names", 1, func() interface{} { return &map[string]float64{} }, nil)
+	if err := ReadStateSnapshot(path, "uuid"); err == nil {
+		t.Error("snapshot accepted with an incompatible section")
+	}
+	if err := LoadStateSection("names", &map[string]float64{}); err == nil {
+		t.Error("section loaded from a rejected snapshot")
+	}
+
+	// A section this build expects but the snapshot doesn't have
+	resetStateSections()
+	RegisterStateSection("names", 1, func() interface{} { return &map[string]string{} }, nil)
+	RegisterStateSection("other", 1, func() interface{} { return &[]int{} }, nil)
+	if err := ReadStateSnapshot(path, "uuid"); err == nil {
+		t.Error("snapshot accepted with a missing section")
+	}
+}
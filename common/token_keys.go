@@ -0,0 +1,168 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// tokenKeys encrypts NAS auth tokens and GPCM login tickets
+type tokenKeys struct {
+	AuthTokenKey     []byte    `json:"auth_token_key"`
+	AuthTokenIV      []byte    `json:"auth_token_iv"`
+	AuthTokenMagic   []byte    `json:"auth_token_magic"`
+	LoginTicketKey   []byte    `json:"login_ticket_key"`
+	LoginTicketIV    []byte    `json:"login_ticket_iv"`
+	LoginTicketMagic []byte    `json:"login_ticket_magic"`
+	Created          time.Time `json:"created"`
+}
+
+type tokenKeyFile struct {
+	Current  tokenKeys  `json:"current"`
+	Previous *tokenKeys `json:"previous,omitempty"`
+	// The previous keys stop verifying after this
+	PreviousExpires time.Time `json:"previous_expires"`
+}
+
+var (
+	tokenKeysMutex sync.RWMutex
+	// Without a key file the keys only last for the life of the process
+	currentTokenKeys        = newTokenKeys()
+	previousTokenKeys       *tokenKeys
+	previousTokenKeysExpire time.Time
+
+	tokenKeyPath     string
+	tokenKeyRotation time.Duration
+	tokenKeyGrace    time.Duration
+)
+
+func newTokenKeys() tokenKeys {
+	return tokenKeys{
+		AuthTokenKey:     generateRandom(16),
+		AuthTokenIV:      generateRandom(16),
+		AuthTokenMagic:   generateRandom(14),
+		LoginTicketKey:   generateRandom(16),
+		LoginTicketIV:    generateRandom(16),
+		LoginTicketMagic: generateRandom(4),
+		Created:          time.Now(),
+	}
+}
+
+func (k tokenKeys) valid() bool {
+	return len(k.AuthTokenKey) == 16 && len(k.AuthTokenIV) == 16 && len(k.AuthTokenMagic) == 14 &&
+		len(k.LoginTicketKey) == 16 && len(k.LoginTicketIV) == 16 && len(k.LoginTicketMagic) == 4
+}
+
+// LoadTokenKeys loads the token keys from the key file, creating it if needed, so tokens issued before a
+// backend reload still verify. The keys are replaced every rotation, and the previous keys keep verifying
+// for the grace period after that. A zero rotation disables rotation.
+func LoadTokenKeys(path string, rotation time.Duration, grace time.Duration) error {
+	tokenKeysMutex.Lock()
+	defer tokenKeysMutex.Unlock()
+
+	tokenKeyPath = path
+	tokenKeyRotation = rotation
+	tokenKeyGrace = grace
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		logging.Notice("COMMON", "Creating token key file", aurora.Cyan(path))
+		currentTokenKeys = newTokenKeys()
+		previousTokenKeys = nil
+		return saveTokenKeys()
+	} else if err != nil {
+		return err
+	}
+
+	var file tokenKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	if !file.Current.valid() || (file.Previous != nil && !file.Previous.valid()) {
+		return errors.New("invalid key length in token key file")
+	}
+
+	currentTokenKeys = file.Current
+	previousTokenKeys = file.Previous
+	previousTokenKeysExpire = file.PreviousExpires
+	return nil
+}
+
+// RotateTokenKeysLoop replaces the token keys once they are older than the rotation period
+func RotateTokenKeysLoop() {
+	for {
+		tokenKeysMutex.Lock()
+		if tokenKeyRotation > 0 && time.Since(currentTokenKeys.Created) >= tokenKeyRotation {
+			if err := rotateTokenKeys(); err != nil {
+				logging.Error("COMMON", "Failed to rotate token keys:", err)
+			} else {
+				logging.Notice("COMMON", "Rotated token keys")
+			}
+		}
+		tokenKeysMutex.Unlock()
+
+		time.Sleep(time.Minute)
+	}
+}
+
+// rotateTokenKeys must be called with tokenKeysMutex held
+func rotateTokenKeys() error {
+	previous := currentTokenKeys
+	previousTokenKeys = &previous
+	previousTokenKeysExpire = time.Now().Add(tokenKeyGrace)
+	currentTokenKeys = newTokenKeys()
+
+	if tokenKeyPath == "" {
+		return nil
+	}
+	return saveTokenKeys()
+}
+
+// saveTokenKeys must be called with tokenKeysMutex held
+func saveTokenKeys() error {
+	data, err := json.Marshal(tokenKeyFile{
+		Current:         currentTokenKeys,
+		Previous:        previousTokenKeys,
+		PreviousExpires: previousTokenKeysExpire,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tokenKeyPath), 0700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash can't leave a truncated key file
+	temp := tokenKeyPath + ".tmp"
+	if err := os.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, tokenKeyPath)
+}
+
+// getTokenKeys returns the keys to verify a token with, current first
+func getTokenKeys() []tokenKeys {
+	tokenKeysMutex.RLock()
+	defer tokenKeysMutex.RUnlock()
+
+	keys := []tokenKeys{currentTokenKeys}
+	if previousTokenKeys != nil && time.Now().Before(previousTokenKeysExpire) {
+		keys = append(keys, *previousTokenKeys)
+	}
+	return keys
+}
+
+func getCurrentTokenKeys() tokenKeys {
+	tokenKeysMutex.RLock()
+	defer tokenKeysMutex.RUnlock()
+
+	return currentTokenKeys
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenKeyRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token_keys.json")
+	if err := LoadTokenKeys(path, time.Hour, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	token, _ := MarshalNASAuthToken("RMCJ", 1, "abcd", 0, 0, 0, "", 0, false, "")
+	ticket := MarshalGPCMLoginTicket(1000)
+
+	tokenKeysMutex.Lock()
+	err := rotateTokenKeys()
+	tokenKeysMutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Issued with the previous key, within the grace period
+	if _, _, _, _, _, _, _, _, _, _, _, _, err := UnmarshalNASAuthToken(token); err != nil {
+		t.Error("auth token rejected after rotation:", err)
+	}
+	if profileId, _, err := UnmarshalGPCMLoginTicket(ticket); err != nil || profileId != 1000 {
+		t.Error("login ticket rejected after rotation:", err)
+	}
+
+	// Reloading from the file keeps the previous key
+	if err := LoadTokenKeys(path, time.Hour, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := UnmarshalGPCMLoginTicket(ticket); err != nil {
+		t.Error("login ticket rejected after reload:", err)
+	}
+
+	tokenKeysMutex.Lock()
+	previousTokenKeysExpire = time.Now().Add(-time.Second)
+	tokenKeysMutex.Unlock()
+
+	if _, _, _, _, _, _, _, _, _, _, _, _, err := UnmarshalNASAuthToken(token); err == nil {
+		t.Error("auth token accepted after the grace period")
+	}
+	if _, _, err := UnmarshalGPCMLoginTicket(ticket); err == nil {
+		t.Error("login ticket accepted after the grace period")
+	}
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+	"wwfc/bridge"
+)
+
+// Datagrams the backend has received from the frontend but a server hasn't read yet
+const udpReadQueueSize = 1024
+
+// UDPConn is a net.PacketConn for a UDP socket owned by the frontend. The frontend keeps the socket open
+// while the backend reloads, forwards each datagram it receives, and sends what is written to it.
+type UDPConn struct {
+	server  string
+	packets chan udpPacket
+	closed  chan struct{}
+	once    sync.Once
+}
+
+type udpPacket struct {
+	addr *net.UDPAddr
+	data []byte
+}
+
+var (
+	udpConns      = map[string]*UDPConn{}
+	udpConnsMutex sync.RWMutex
+)
+
+// ListenUDP returns the connection for the frontend's UDP socket for server, replacing any previous one
+func ListenUDP(server string) *UDPConn {
+	conn := &UDPConn{
+		server:  server,
+		packets: make(chan udpPacket, udpReadQueueSize),
+		closed:  make(chan struct{}),
+	}
+
+	udpConnsMutex.Lock()
+	udpConns[server] = conn
+	udpConnsMutex.Unlock()
+
+	return conn
+}
+
+// HandleUDPPacket is called when the frontend forwards a datagram for server
+func HandleUDPPacket(server string, address string, data []byte) error {
+	udpConnsMutex.RLock()
+	conn := udpConns[server]
+	udpConnsMutex.RUnlock()
+
+	if conn == nil {
+		return errors.New("no UDP listener for " + server)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case conn.packets <- udpPacket{addr: addr, data: data}:
+		return nil
+	case <-conn.closed:
+		return net.ErrClosed
+	}
+}
+
+// ReadFrom blocks until the frontend forwards a datagram or the connection is closed
+func (c *UDPConn) ReadFrom(buffer []byte) (int, net.Addr, error) {
+	select {
+	case packet := <-c.packets:
+		return copy(buffer, packet.data), packet.addr, nil
+	case <-c.closed:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+// WriteTo sends a datagram from the frontend's socket
+func (c *UDPConn) WriteTo(data []byte, addr net.Addr) (int, error) {
+	// The caller may reuse the buffer once this returns
+	payload := append([]byte{}, data...)
+
+	err := sendToFrontend(bridge.Frame{Type: bridge.FrameDatagram, Server: c.server, Address: addr.String(), Data: payload})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+// Close stops reading. The frontend's socket stays open.
+func (c *UDPConn) Close() error {
+	c.once.Do(func() {
+		close(c.closed)
+	})
+
+	return nil
+}
+
+func (c *UDPConn) LocalAddr() net.Addr {
+	return &net.UDPAddr{}
+}
+
+func (c *UDPConn) SetDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (c *UDPConn) SetReadDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
+
+func (c *UDPConn) SetWriteDeadline(t time.Time) error {
+	return os.ErrNoDeadline
+}
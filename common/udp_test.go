@@ -0,0 +1,33 @@
+package common
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestUDPConnReadFrom(t *testing.T) {
+	conn := ListenUDP("test")
+
+	if err := HandleUDPPacket("test", "192.0.2.1:27900", []byte("heartbeat")); err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, 1024)
+	n, addr, err := conn.ReadFrom(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buffer[:n]) != "heartbeat" || addr.String() != "192.0.2.1:27900" {
+		t.Errorf("unexpected packet %q from %s", buffer[:n], addr)
+	}
+
+	conn.Close()
+	if _, _, err := conn.ReadFrom(buffer); !errors.Is(err, net.ErrClosed) {
+		t.Error("read from a closed connection:", err)
+	}
+
+	if err := HandleUDPPacket("unknown", "192.0.2.1:27900", nil); err == nil {
+		t.Error("packet accepted for a server without a listener")
+	}
+}
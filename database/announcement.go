@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertAnnouncement      = `INSERT INTO announcements (message, game_name, profile_id, send_at, created_by, created_at) VALUES ($1, NULLIF($2, ''), NULLIF($3, 0), $4, $5, $6) RETURNING id`
+	ClaimAnnouncements      = `UPDATE announcements SET sent_at = $1 WHERE sent_at IS NULL AND send_at <= $1 RETURNING id, message, COALESCE(game_name, ''), COALESCE(profile_id, 0), send_at, sent_at, created_by, created_at`
+	UpdateAnnouncementCount = `UPDATE announcements SET recipients = $2 WHERE id = $1`
+	GetAnnouncementList     = `SELECT id, message, COALESCE(game_name, ''), COALESCE(profile_id, 0), send_at, sent_at, recipients, created_by, created_at FROM announcements WHERE sent_at IS NULL OR sent_at > $1 ORDER BY send_at DESC`
+	DeletePendingAnnounce   = `DELETE FROM announcements WHERE id = $1 AND sent_at IS NULL`
+	DoesAnnouncementExist   = `SELECT EXISTS(SELECT 1 FROM announcements WHERE id = $1)`
+)
+
+// Announcement is a message pushed to players over GPCM. An empty GameName and a zero ProfileId mean everyone.
+type Announcement struct {
+	AnnouncementId int64      `json:"id"`
+	Message        string     `json:"message"`
+	GameName       string     `json:"game,omitempty"`
+	ProfileId      uint32     `json:"pid,omitempty"`
+	SendAt         time.Time  `json:"send_at"`
+	SentAt         *time.Time `json:"sent_at"`
+	Recipients     *int       `json:"recipients"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created"`
+}
+
+var (
+	ErrAnnouncementNotFound = errors.New("announcement does not exist")
+	ErrAnnouncementSent     = errors.New("announcement has already been sent")
+)
+
+func AddAnnouncement(pool *pgxpool.Pool, ctx context.Context, message string, gameName string, profileId uint32, sendAt time.Time, createdBy string) (int64, error) {
+	var announcementId int64
+	err := pool.QueryRow(ctx, InsertAnnouncement, message, gameName, profileId, sendAt, createdBy, time.Now()).Scan(&announcementId)
+	return announcementId, err
+}
+
+// ClaimDueAnnouncements marks every announcement due by now as sent and returns them.
+// Each announcement is only returned once, even with several callers.
+func ClaimDueAnnouncements(pool *pgxpool.Pool, ctx context.Context) ([]Announcement, error) {
+	rows, err := pool.Query(ctx, ClaimAnnouncements, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []Announcement{}
+	for rows.Next() {
+		var announcement Announcement
+		err := rows.Scan(&announcement.AnnouncementId, &announcement.Message, &announcement.GameName, &announcement.ProfileId, &announcement.SendAt, &announcement.SentAt, &announcement.CreatedBy, &announcement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		announcements = append(announcements, announcement)
+	}
+
+	return announcements, rows.Err()
+}
+
+func SetAnnouncementRecipients(pool *pgxpool.Pool, ctx context.Context, announcementId int64, recipients int) error {
+	_, err := pool.Exec(ctx, UpdateAnnouncementCount, announcementId, recipients)
+	return err
+}
+
+// GetAnnouncements returns the pending announcements and those sent after the given time, newest first
+func GetAnnouncements(pool *pgxpool.Pool, ctx context.Context, sentAfter time.Time) ([]Announcement, error) {
+	rows, err := pool.Query(ctx, GetAnnouncementList, sentAfter)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := []Announcement{}
+	for rows.Next() {
+		var announcement Announcement
+		err := rows.Scan(&announcement.AnnouncementId, &announcement.Message, &announcement.GameName, &announcement.ProfileId, &announcement.SendAt, &announcement.SentAt, &announcement.Recipients, &announcement.CreatedBy, &announcement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		announcements = append(announcements, announcement)
+	}
+
+	return announcements, rows.Err()
+}
+
+// CancelAnnouncement deletes an announcement that hasn't been sent yet
+func CancelAnnouncement(pool *pgxpool.Pool, ctx context.Context, announcementId int64) error {
+	result, err := pool.Exec(ctx, DeletePendingAnnounce, announcementId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() != 0 {
+		return nil
+	}
+
+	var exists bool
+	err = pool.QueryRow(ctx, DoesAnnouncementExist, announcementId).Scan(&exists)
+	if err != nil {
+		return err
+	} else if !exists {
+		return ErrAnnouncementNotFound
+	}
+
+	return ErrAnnouncementSent
+}
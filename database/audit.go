@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertAuditEntry = `INSERT INTO audit_log (moderator, action, profile_id, params, ip_address, created_at) VALUES ($1, $2, $3, $4::jsonb, $5, $6)`
+	SearchAuditLog   = `SELECT id, moderator, action, COALESCE(profile_id, 0), params::text, ip_address, created_at FROM audit_log WHERE ($1 = '' OR moderator = $1) AND ($2 = 0 OR profile_id = $2) AND ($3::timestamp IS NULL OR created_at >= $3) AND ($4::timestamp IS NULL OR created_at < $4) ORDER BY id DESC LIMIT $5 OFFSET $6`
+)
+
+type AuditEntry struct {
+	EntryId   int64             `json:"id"`
+	Moderator string            `json:"moderator"`
+	Action    string            `json:"action"`
+	ProfileId uint32            `json:"pid,omitempty"`
+	Params    map[string]string `json:"params"`
+	IpAddress string            `json:"ip"`
+	CreatedAt time.Time         `json:"created"`
+}
+
+// AuditFilter narrows down GetAuditLog. Zero values match everything.
+type AuditFilter struct {
+	Moderator string
+	ProfileId uint32
+	After     *time.Time
+	Before    *time.Time
+	Limit     int
+	Offset    int
+}
+
+// AddAuditEntry appends a moderation action to the audit log. A profile ID of 0 means the action has no target player.
+func AddAuditEntry(pool *pgxpool.Pool, ctx context.Context, moderator string, action string, profileId uint32, params map[string]string, ipAddress string) error {
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	paramsJson, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	var target *uint32
+	if profileId != 0 {
+		target = &profileId
+	}
+
+	_, err = pool.Exec(ctx, InsertAuditEntry, moderator, action, target, string(paramsJson), ipAddress, time.Now())
+	return err
+}
+
+// GetAuditLog returns the matching audit log entries, newest first
+func GetAuditLog(pool *pgxpool.Pool, ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	rows, err := pool.Query(ctx, SearchAuditLog, filter.Moderator, filter.ProfileId, toLocalTimestamp(filter.After), toLocalTimestamp(filter.Before), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var paramsJson string
+		err := rows.Scan(&entry.EntryId, &entry.Moderator, &entry.Action, &entry.ProfileId, &paramsJson, &entry.IpAddress, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.CreatedAt = *fromLocalTimestamp(&entry.CreatedAt)
+
+		err = json.Unmarshal([]byte(paramsJson), &entry.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertBan         = `INSERT INTO bans (profile_id, ng_device_id, ip_address, tos, moderator, reason, reason_hidden, created_at, expires_at) SELECT profile_id, ng_device_id, last_ip_address, $2, $3, $4, $5, $6, $7 FROM users WHERE profile_id = $1 RETURNING id`
+	SearchUserBan     = `SELECT id, tos, COALESCE(ng_device_id, 0), reason, expires_at FROM bans WHERE revoked_at IS NULL AND (profile_id = $1 OR (ng_device_id = $2 AND ng_device_id <> 0) OR (ip_address = $3 AND ip_address <> '')) AND (expires_at IS NULL OR expires_at > $4) ORDER BY tos DESC, created_at DESC LIMIT 1`
+	RevokeUserBans    = `UPDATE bans SET revoked_at = $2, revoked_by = $3 WHERE profile_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $2)`
+	GetUserBanHistory = `SELECT id, profile_id, COALESCE(ng_device_id, 0), COALESCE(ip_address, ''), tos, moderator, reason, COALESCE(reason_hidden, ''), created_at, expires_at, revoked_at, COALESCE(revoked_by, '') FROM bans WHERE profile_id = $1 ORDER BY created_at DESC`
+	UpdateBanExpires  = `UPDATE bans SET expires_at = $2 WHERE id = $1 RETURNING profile_id`
+	InsertBanAppeal   = `INSERT INTO ban_appeals (ban_id, author, note, created_at) VALUES ($1, $2, $3, $4)`
+	GetBanAppeals     = `SELECT ban_appeals.id, ban_id, author, note, ban_appeals.created_at FROM ban_appeals JOIN bans ON bans.id = ban_appeals.ban_id WHERE bans.profile_id = $1 ORDER BY ban_appeals.created_at`
+	GetBanProfileId   = `SELECT profile_id FROM bans WHERE id = $1`
+)
+
+type Ban struct {
+	BanId        int64       `json:"id"`
+	ProfileId    uint32      `json:"pid"`
+	NgDeviceId   uint32      `json:"device_id"`
+	IpAddress    string      `json:"ip"`
+	TOS          bool        `json:"tos"`
+	Moderator    string      `json:"moderator"`
+	Reason       string      `json:"reason"`
+	ReasonHidden string      `json:"reason_hidden"`
+	CreatedAt    time.Time   `json:"created"`
+	ExpiresAt    *time.Time  `json:"expires"`
+	RevokedAt    *time.Time  `json:"revoked,omitempty"`
+	RevokedBy    string      `json:"revoked_by,omitempty"`
+	Appeals      []BanAppeal `json:"appeals"`
+}
+
+type BanAppeal struct {
+	AppealId  int64     `json:"id"`
+	BanId     int64     `json:"ban_id"`
+	Author    string    `json:"author"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created"`
+}
+
+var ErrBanNotFound = errors.New("ban does not exist")
+
+// searchUserBan finds the most severe active ban matching the profile, device ID or IP address
+func searchUserBan(pool *pgxpool.Pool, ctx context.Context, profileId uint32, ngDeviceId uint32, ipAddress string) (ban Ban, exists bool, err error) {
+	err = pool.QueryRow(ctx, SearchUserBan, profileId, ngDeviceId, ipAddress, time.Now()).Scan(&ban.BanId, &ban.TOS, &ban.NgDeviceId, &ban.Reason, &ban.ExpiresAt)
+	if err == pgx.ErrNoRows {
+		return Ban{}, false, nil
+	}
+
+	// The expiry is shown to the player in UTC
+	ban.fromLocalTimestamps()
+	return ban, err == nil, err
+}
+
+// fromLocalTimestamps restores the local time zone on the times read from the bans table
+func (ban *Ban) fromLocalTimestamps() {
+	ban.CreatedAt = *fromLocalTimestamp(&ban.CreatedAt)
+	ban.ExpiresAt = fromLocalTimestamp(ban.ExpiresAt)
+	ban.RevokedAt = fromLocalTimestamp(ban.RevokedAt)
+}
+
+func BanUser(pool *pgxpool.Pool, ctx context.Context, profileId uint32, tos bool, length time.Duration, reason string, reasonHidden string, moderator string) bool {
+	timeNow := time.Now()
+
+	var banId int64
+	err := pool.QueryRow(ctx, InsertBan, profileId, tos, moderator, reason, reasonHidden, timeNow, timeNow.Add(length)).Scan(&banId)
+	return err == nil
+}
+
+func UnbanUser(pool *pgxpool.Pool, ctx context.Context, profileId uint32, moderator string) bool {
+	_, err := pool.Exec(ctx, RevokeUserBans, profileId, time.Now(), moderator)
+	return err == nil
+}
+
+// GetBanHistory returns every ban ever issued to the profile, newest first, along with its appeal notes
+func GetBanHistory(pool *pgxpool.Pool, ctx context.Context, profileId uint32) ([]Ban, error) {
+	rows, err := pool.Query(ctx, GetUserBanHistory, profileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := []Ban{}
+	banIndex := map[int64]int{}
+	for rows.Next() {
+		ban := Ban{Appeals: []BanAppeal{}}
+		err := rows.Scan(&ban.BanId, &ban.ProfileId, &ban.NgDeviceId, &ban.IpAddress, &ban.TOS, &ban.Moderator, &ban.Reason, &ban.ReasonHidden, &ban.CreatedAt, &ban.ExpiresAt, &ban.RevokedAt, &ban.RevokedBy)
+		if err != nil {
+			return nil, err
+		}
+
+		ban.fromLocalTimestamps()
+
+		banIndex[ban.BanId] = len(bans)
+		bans = append(bans, ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = pool.Query(ctx, GetBanAppeals, profileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var appeal BanAppeal
+		err := rows.Scan(&appeal.AppealId, &appeal.BanId, &appeal.Author, &appeal.Note, &appeal.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		appeal.CreatedAt = *fromLocalTimestamp(&appeal.CreatedAt)
+
+		if index, ok := banIndex[appeal.BanId]; ok {
+			bans[index].Appeals = append(bans[index].Appeals, appeal)
+		}
+	}
+
+	return bans, rows.Err()
+}
+
+// UpdateBanExpiry changes when a ban expires and returns the banned profile ID
+func UpdateBanExpiry(pool *pgxpool.Pool, ctx context.Context, banId int64, expires time.Time) (uint32, error) {
+	var profileId uint32
+	err := pool.QueryRow(ctx, UpdateBanExpires, banId, expires).Scan(&profileId)
+	if err == pgx.ErrNoRows {
+		return 0, ErrBanNotFound
+	}
+
+	return profileId, err
+}
+
+// AddBanAppeal adds a note to a ban's appeal history and returns the banned profile ID
+func AddBanAppeal(pool *pgxpool.Pool, ctx context.Context, banId int64, author string, note string) (uint32, error) {
+	var profileId uint32
+	err := pool.QueryRow(ctx, GetBanProfileId, banId).Scan(&profileId)
+	if err == pgx.ErrNoRows {
+		return 0, ErrBanNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	_, err = pool.Exec(ctx, InsertBanAppeal, banId, author, note, time.Now())
+	return profileId, err
+}
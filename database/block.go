@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertBlock  = `INSERT INTO blocks (profile_id, blocked_id, created_at) VALUES ($1, $2, $3) ON CONFLICT (profile_id, blocked_id) DO NOTHING`
+	DeleteBlock  = `DELETE FROM blocks WHERE profile_id = $1 AND blocked_id = $2`
+	GetBlockList = `SELECT blocked_id FROM blocks WHERE profile_id = $1 ORDER BY created_at`
+)
+
+// AddBlock adds blockedId to profileId's block list. Returns false if it was already blocked.
+func AddBlock(pool *pgxpool.Pool, ctx context.Context, profileId uint32, blockedId uint32) (bool, error) {
+	result, err := pool.Exec(ctx, InsertBlock, profileId, blockedId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() != 0, nil
+}
+
+// RemoveBlock removes blockedId from profileId's block list. Returns false if it wasn't blocked.
+func RemoveBlock(pool *pgxpool.Pool, ctx context.Context, profileId uint32, blockedId uint32) (bool, error) {
+	result, err := pool.Exec(ctx, DeleteBlock, profileId, blockedId)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() != 0, nil
+}
+
+func GetBlocks(pool *pgxpool.Pool, ctx context.Context, profileId uint32) ([]uint32, error) {
+	rows, err := pool.Query(ctx, GetBlockList, profileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []uint32{}
+	for rows.Next() {
+		var blockedId uint32
+		if err := rows.Scan(&blockedId); err != nil {
+			return nil, err
+		}
+
+		blocked = append(blocked, blockedId)
+	}
+
+	return blocked, rows.Err()
+}
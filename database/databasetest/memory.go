@@ -0,0 +1,113 @@
+// Package databasetest provides in-memory implementations of the database repositories, so modules can be
+// tested without a PostgreSQL server.
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+	"wwfc/database"
+)
+
+// FriendInfoRepository is an in-memory database.FriendInfoRepository
+type FriendInfoRepository struct {
+	mutex sync.Mutex
+	info  map[uint32]string
+}
+
+func NewFriendInfoRepository() *FriendInfoRepository {
+	return &FriendInfoRepository{info: map[uint32]string{}}
+}
+
+func (r *FriendInfoRepository) GetMKWFriendInfo(ctx context.Context, profileId uint32) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.info[profileId]
+}
+
+func (r *FriendInfoRepository) UpdateMKWFriendInfo(ctx context.Context, profileId uint32, info string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.info[profileId] = info
+}
+
+// MotdRepository is an in-memory database.MotdRepository
+type MotdRepository struct {
+	mutex  sync.Mutex
+	motds  []database.Motd
+	nextId int
+}
+
+func NewMotdRepository() *MotdRepository {
+	return &MotdRepository{nextId: 1}
+}
+
+func (r *MotdRepository) AddMotd(ctx context.Context, motd database.Motd) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	motd.MotdId = r.nextId
+	motd.CreatedAt = time.Now()
+	r.nextId++
+
+	r.motds = append(r.motds, motd)
+	return motd.MotdId, nil
+}
+
+func (r *MotdRepository) GetMotds(ctx context.Context) ([]database.Motd, error) {
+	now := time.Now()
+	return r.list(func(motd database.Motd) bool {
+		return motd.EndsAt == nil || motd.EndsAt.After(now)
+	}), nil
+}
+
+func (r *MotdRepository) GetActiveMotds(ctx context.Context) ([]database.Motd, error) {
+	now := time.Now()
+	return r.list(func(motd database.Motd) bool {
+		return (motd.StartsAt == nil || !motd.StartsAt.After(now)) && (motd.EndsAt == nil || motd.EndsAt.After(now))
+	}), nil
+}
+
+func (r *MotdRepository) DeleteMotd(ctx context.Context, motdId int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, motd := range r.motds {
+		if motd.MotdId == motdId {
+			r.motds = append(r.motds[:i], r.motds[i+1:]...)
+			return nil
+		}
+	}
+
+	return database.ErrMotdNotFound
+}
+
+// list returns the entries matching filter in the same order as the database: highest priority, then newest first
+func (r *MotdRepository) list(filter func(motd database.Motd) bool) []database.Motd {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	motds := []database.Motd{}
+	for _, motd := range r.motds {
+		if filter(motd) {
+			motds = append(motds, motd)
+		}
+	}
+
+	sort.Slice(motds, func(i, j int) bool {
+		if motds[i].Priority != motds[j].Priority {
+			return motds[i].Priority > motds[j].Priority
+		}
+		return motds[i].MotdId > motds[j].MotdId
+	})
+
+	return motds
+}
+
+var (
+	_ database.FriendInfoRepository = (*FriendInfoRepository)(nil)
+	_ database.MotdRepository       = (*MotdRepository)(nil)
+)
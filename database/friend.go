@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertFriendship        = `INSERT INTO friendships (profile_id, friend_id, added_at) VALUES ($1, $2, $3) ON CONFLICT (profile_id, friend_id) DO NOTHING`
+	AuthorizeFriendshipPair = `UPDATE friendships SET authorized_at = $3 WHERE ((profile_id = $1 AND friend_id = $2) OR (profile_id = $2 AND friend_id = $1)) AND authorized_at IS NULL`
+	DeleteFriendship        = `DELETE FROM friendships WHERE profile_id = $1 AND friend_id = $2`
+	RevokeFriendship        = `UPDATE friendships SET authorized_at = NULL WHERE profile_id = $2 AND friend_id = $1`
+	GetFriendshipList       = `SELECT friend_id, added_at, authorized_at FROM friendships WHERE profile_id = $1 ORDER BY added_at`
+	IsFriendshipMutual      = `SELECT EXISTS(SELECT 1 FROM friendships WHERE profile_id = $1 AND friend_id = $2 AND authorized_at IS NOT NULL)`
+)
+
+// Friendship is one entry in a player's buddy list. AuthorizedAt is set once both players have added each other.
+type Friendship struct {
+	FriendId     uint32     `json:"pid"`
+	AddedAt      time.Time  `json:"added"`
+	AuthorizedAt *time.Time `json:"authorized"`
+}
+
+// AddFriend records that profileId added friendId to their buddy list
+func AddFriend(pool *pgxpool.Pool, ctx context.Context, profileId uint32, friendId uint32) error {
+	_, err := pool.Exec(ctx, InsertFriendship, profileId, friendId, time.Now())
+	return err
+}
+
+// AuthorizeFriendship marks both directions of a mutual friendship as authorized
+func AuthorizeFriendship(pool *pgxpool.Pool, ctx context.Context, profileId uint32, friendId uint32) error {
+	_, err := pool.Exec(ctx, AuthorizeFriendshipPair, profileId, friendId, time.Now())
+	return err
+}
+
+// RemoveFriend deletes friendId from profileId's buddy list, which also ends the authorization in the other direction
+func RemoveFriend(pool *pgxpool.Pool, ctx context.Context, profileId uint32, friendId uint32) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, DeleteFriendship, profileId, friendId); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, RevokeFriendship, profileId, friendId); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetFriends returns the profile's buddy list, oldest first
+func GetFriends(pool *pgxpool.Pool, ctx context.Context, profileId uint32) ([]Friendship, error) {
+	rows, err := pool.Query(ctx, GetFriendshipList, profileId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friends := []Friendship{}
+	for rows.Next() {
+		var friend Friendship
+		if err := rows.Scan(&friend.FriendId, &friend.AddedAt, &friend.AuthorizedAt); err != nil {
+			return nil, err
+		}
+
+		friends = append(friends, friend)
+	}
+
+	return friends, rows.Err()
+}
+
+// IsFriendAuthorized checks if profileId has an authorized friendship with friendId
+func IsFriendAuthorized(pool *pgxpool.Pool, ctx context.Context, profileId uint32, friendId uint32) (bool, error) {
+	var authorized bool
+	err := pool.QueryRow(ctx, IsFriendshipMutual, profileId, friendId).Scan(&authorized)
+	return authorized, err
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertFriendRequest        = `INSERT INTO friend_requests (from_profile_id, to_profile_id, created_at, expires_at) VALUES ($1, $2, $3, $4) ON CONFLICT (from_profile_id, to_profile_id) DO UPDATE SET created_at = $3, expires_at = $4, delivered_at = NULL`
+	GetPendingFriendRequests   = `SELECT id, from_profile_id, to_profile_id, created_at, expires_at FROM friend_requests WHERE (from_profile_id = $1 OR to_profile_id = $1) AND delivered_at IS NULL AND expires_at > $2 ORDER BY created_at`
+	UpdateFriendRequestDeliver = `UPDATE friend_requests SET delivered_at = $3 WHERE from_profile_id = $1 AND to_profile_id = $2 AND delivered_at IS NULL`
+	DeleteFriendRequestPair    = `DELETE FROM friend_requests WHERE from_profile_id = $1 AND to_profile_id = $2`
+	DeleteExpiredRequests      = `DELETE FROM friend_requests WHERE expires_at <= $1`
+)
+
+// FriendRequest is an addbuddy that couldn't be completed because the other player was offline
+type FriendRequest struct {
+	RequestId     int64     `json:"id"`
+	FromProfileId uint32    `json:"from"`
+	ToProfileId   uint32    `json:"to"`
+	CreatedAt     time.Time `json:"created"`
+	ExpiresAt     time.Time `json:"expires"`
+}
+
+// AddFriendRequest stores a pending friend request, replacing any earlier request between the same players
+func AddFriendRequest(pool *pgxpool.Pool, ctx context.Context, fromProfileId uint32, toProfileId uint32, length time.Duration) error {
+	now := time.Now()
+	_, err := pool.Exec(ctx, InsertFriendRequest, fromProfileId, toProfileId, now, now.Add(length))
+	return err
+}
+
+// GetFriendRequests returns the undelivered, unexpired requests sent to or from the profile, oldest first
+func GetFriendRequests(pool *pgxpool.Pool, ctx context.Context, profileId uint32) ([]FriendRequest, error) {
+	rows, err := pool.Query(ctx, GetPendingFriendRequests, profileId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []FriendRequest{}
+	for rows.Next() {
+		var request FriendRequest
+		if err := rows.Scan(&request.RequestId, &request.FromProfileId, &request.ToProfileId, &request.CreatedAt, &request.ExpiresAt); err != nil {
+			return nil, err
+		}
+
+		requests = append(requests, request)
+	}
+
+	return requests, rows.Err()
+}
+
+// MarkFriendRequestDelivered returns false if there was no pending request, so it can't be delivered twice
+func MarkFriendRequestDelivered(pool *pgxpool.Pool, ctx context.Context, fromProfileId uint32, toProfileId uint32) (bool, error) {
+	result, err := pool.Exec(ctx, UpdateFriendRequestDeliver, fromProfileId, toProfileId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() != 0, nil
+}
+
+func DeleteFriendRequest(pool *pgxpool.Pool, ctx context.Context, fromProfileId uint32, toProfileId uint32) error {
+	_, err := pool.Exec(ctx, DeleteFriendRequestPair, fromProfileId, toProfileId)
+	return err
+}
+
+// DeleteExpiredFriendRequests removes requests that can no longer be delivered and returns how many were removed
+func DeleteExpiredFriendRequests(pool *pgxpool.Pool, ctx context.Context) (int64, error) {
+	result, err := pool.Exec(ctx, DeleteExpiredRequests, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
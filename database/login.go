@@ -4,11 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
 	"wwfc/common"
 	"wwfc/logging"
 
-	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/logrusorgru/aurora/v3"
 )
@@ -100,29 +98,27 @@ func LoginUserToGPCM(pool *pgxpool.Pool, ctx context.Context, userId uint64, gsb
 		return User{}, err
 	}
 
+	if err := recordLogin(pool, ctx, user.ProfileId, user.NgDeviceId, ipAddress, ingamesn); err != nil {
+		logging.Error("DATABASE", "Failed to record login history for", aurora.Cyan(user.ProfileId), "-", err)
+	}
+
 	// Find ban from device ID or IP address
-	var banExists bool
-	var banTOS bool
-	var bannedDeviceId uint32
-	timeNow := time.Now()
-	err = pool.QueryRow(ctx, SearchUserBan, user.ProfileId, user.NgDeviceId, ipAddress, timeNow).Scan(&banExists, &banTOS, &bannedDeviceId)
+	ban, banExists, err := searchUserBan(pool, ctx, user.ProfileId, user.NgDeviceId, ipAddress)
 	if err != nil {
-		if err != pgx.ErrNoRows {
-			return User{}, err
-		}
-
-		banExists = false
+		return User{}, err
 	}
 
 	if banExists {
-		if banTOS {
+		if ban.TOS {
 			logging.Warn("DATABASE", "Profile", aurora.Cyan(user.ProfileId), "is banned")
-			return User{RestrictedDeviceId: bannedDeviceId}, ErrProfileBannedTOS
+			return User{RestrictedDeviceId: ban.NgDeviceId, BanReason: ban.Reason, BanExpires: ban.ExpiresAt}, ErrProfileBannedTOS
 		}
 
 		logging.Warn("DATABASE", "Profile", aurora.Cyan(user.ProfileId), "is restricted")
 		user.Restricted = true
-		user.RestrictedDeviceId = bannedDeviceId
+		user.RestrictedDeviceId = ban.NgDeviceId
+		user.BanReason = ban.Reason
+		user.BanExpires = ban.ExpiresAt
 	}
 
 	var Trusted bool
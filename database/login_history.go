@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertLoginHistory = `INSERT INTO login_history (profile_id, ng_device_id, ip_address, ingamesn, first_seen, last_seen) VALUES ($1, $2, $3, $4, $5, $5) ON CONFLICT (profile_id, ng_device_id, ip_address, ingamesn) DO UPDATE SET last_seen = $5, login_count = login_history.login_count + 1`
+	GetProfileLogins   = `SELECT profile_id, ng_device_id, ip_address, ingamesn, first_seen, last_seen, login_count FROM login_history WHERE profile_id = ANY($1) ORDER BY last_seen DESC`
+	GetLinkedLogins    = `SELECT profile_id, ng_device_id, ip_address, ingamesn, first_seen, last_seen, login_count FROM login_history WHERE (ng_device_id = ANY($1) AND ng_device_id <> 0) OR (ip_address = ANY($2) AND ip_address <> '') ORDER BY last_seen DESC LIMIT $3`
+	GetMatchingBans    = `SELECT id, profile_id, COALESCE(ng_device_id, 0), COALESCE(ip_address, ''), tos, moderator, reason, COALESCE(reason_hidden, ''), created_at, expires_at FROM bans WHERE revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3) AND (profile_id = ANY($1) OR (ng_device_id = ANY($2) AND ng_device_id <> 0)) ORDER BY created_at DESC`
+)
+
+// LoginRecord is one combination of profile, device, address and in-game name seen at GPCM login
+type LoginRecord struct {
+	ProfileId  uint32    `json:"pid"`
+	NgDeviceId uint32    `json:"device_id"`
+	IpAddress  string    `json:"ip"`
+	InGameName string    `json:"name"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+	LoginCount int       `json:"count"`
+}
+
+// recordLogin is called from LoginUserToGPCM before the ban check, so attempts by banned players are kept too
+func recordLogin(pool *pgxpool.Pool, ctx context.Context, profileId uint32, ngDeviceId uint32, ipAddress string, ingamesn string) error {
+	_, err := pool.Exec(ctx, InsertLoginHistory, profileId, ngDeviceId, ipAddress, ingamesn, time.Now())
+	return err
+}
+
+// GetLoginHistory returns every login record of the given profiles, most recent first
+func GetLoginHistory(pool *pgxpool.Pool, ctx context.Context, profileIds []uint32) ([]LoginRecord, error) {
+	rows, err := pool.Query(ctx, GetProfileLogins, toInt64s(profileIds))
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoginRecords(rows)
+}
+
+// FindLinkedLogins returns up to limit login records sharing any of the device IDs or IP addresses
+func FindLinkedLogins(pool *pgxpool.Pool, ctx context.Context, ngDeviceIds []uint32, ipAddresses []string, limit int) ([]LoginRecord, error) {
+	rows, err := pool.Query(ctx, GetLinkedLogins, toInt64s(ngDeviceIds), ipAddresses, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanLoginRecords(rows)
+}
+
+// GetActiveBans returns the active bans issued to any of the profiles or device IDs
+func GetActiveBans(pool *pgxpool.Pool, ctx context.Context, profileIds []uint32, ngDeviceIds []uint32) ([]Ban, error) {
+	rows, err := pool.Query(ctx, GetMatchingBans, toInt64s(profileIds), toInt64s(ngDeviceIds), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := []Ban{}
+	for rows.Next() {
+		var ban Ban
+		err := rows.Scan(&ban.BanId, &ban.ProfileId, &ban.NgDeviceId, &ban.IpAddress, &ban.TOS, &ban.Moderator, &ban.Reason, &ban.ReasonHidden, &ban.CreatedAt, &ban.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		ban.fromLocalTimestamps()
+
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
+func scanLoginRecords(rows pgx.Rows) ([]LoginRecord, error) {
+	defer rows.Close()
+
+	records := []LoginRecord{}
+	for rows.Next() {
+		var record LoginRecord
+		err := rows.Scan(&record.ProfileId, &record.NgDeviceId, &record.IpAddress, &record.InGameName, &record.FirstSeen, &record.LastSeen, &record.LoginCount)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// bigint columns are compared against int64 arrays
+func toInt64s(values []uint32) []int64 {
+	result := make([]int64, len(values))
+	for i, value := range values {
+		result[i] = int64(value)
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertLoginSession    = `INSERT INTO login_sessions (profile_id, game_name, game_code, host_platform, ng_device_id, ip_address, login_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	UpdateLoginSessionEnd = `UPDATE login_sessions SET logout_at = $2, disconnect_reason = $3 WHERE id = $1 AND logout_at IS NULL`
+	CloseLoginSessions    = `UPDATE login_sessions SET logout_at = $1, disconnect_reason = $2 WHERE logout_at IS NULL`
+	GetProfileSessions    = `SELECT id, profile_id, game_name, game_code, host_platform, ng_device_id, ip_address, login_at, logout_at, COALESCE(disconnect_reason, '') FROM login_sessions WHERE profile_id = $1 ORDER BY login_at DESC LIMIT $2`
+	GetProfilePlaytime    = `SELECT COALESCE(SUM(EXTRACT(EPOCH FROM COALESCE(logout_at, $2) - login_at)), 0)::bigint FROM login_sessions WHERE profile_id = $1`
+	GetProfilesLastSeen   = `SELECT profile_id, MAX(COALESCE(logout_at, login_at)) FROM login_sessions WHERE profile_id = ANY($1) GROUP BY profile_id`
+)
+
+// LoginSession is one GPCM login, from the login response until the connection closed
+type LoginSession struct {
+	SessionId        int64      `json:"id"`
+	ProfileId        uint32     `json:"pid"`
+	GameName         string     `json:"game"`
+	GameCode         string     `json:"game_code"`
+	HostPlatform     string     `json:"host_platform"`
+	NgDeviceId       uint32     `json:"device_id"`
+	IpAddress        string     `json:"ip"`
+	LoginAt          time.Time  `json:"login"`
+	LogoutAt         *time.Time `json:"logout"`
+	DisconnectReason string     `json:"disconnect_reason,omitempty"`
+}
+
+func StartLoginSession(pool *pgxpool.Pool, ctx context.Context, session LoginSession) (int64, error) {
+	var sessionId int64
+	err := pool.QueryRow(ctx, InsertLoginSession, session.ProfileId, session.GameName, session.GameCode, session.HostPlatform, session.NgDeviceId, session.IpAddress, session.LoginAt).Scan(&sessionId)
+	return sessionId, err
+}
+
+func EndLoginSession(pool *pgxpool.Pool, ctx context.Context, sessionId int64, reason string) error {
+	_, err := pool.Exec(ctx, UpdateLoginSessionEnd, sessionId, time.Now(), reason)
+	return err
+}
+
+// CloseOpenLoginSessions ends the sessions left open when the server stopped without saving its state
+func CloseOpenLoginSessions(pool *pgxpool.Pool, ctx context.Context, reason string) (int64, error) {
+	result, err := pool.Exec(ctx, CloseLoginSessions, time.Now(), reason)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetLoginSessions returns the profile's most recent sessions, newest first
+func GetLoginSessions(pool *pgxpool.Pool, ctx context.Context, profileId uint32, limit int) ([]LoginSession, error) {
+	rows, err := pool.Query(ctx, GetProfileSessions, profileId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []LoginSession{}
+	for rows.Next() {
+		var session LoginSession
+		err := rows.Scan(&session.SessionId, &session.ProfileId, &session.GameName, &session.GameCode, &session.HostPlatform, &session.NgDeviceId, &session.IpAddress, &session.LoginAt, &session.LogoutAt, &session.DisconnectReason)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// GetPlaytime adds up the length of every session, counting open ones until now
+func GetPlaytime(pool *pgxpool.Pool, ctx context.Context, profileId uint32) (time.Duration, error) {
+	var seconds int64
+	err := pool.QueryRow(ctx, GetProfilePlaytime, profileId, time.Now()).Scan(&seconds)
+	return time.Duration(seconds) * time.Second, err
+}
+
+// GetLastSeen returns when each profile last logged out, or logged in if still connected. Profiles never seen are left out.
+func GetLastSeen(pool *pgxpool.Pool, ctx context.Context, profileIds []uint32) (map[uint32]time.Time, error) {
+	rows, err := pool.Query(ctx, GetProfilesLastSeen, toInt64s(profileIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lastSeen := map[uint32]time.Time{}
+	for rows.Next() {
+		var profileId uint32
+		var seen time.Time
+		if err := rows.Scan(&profileId, &seen); err != nil {
+			return nil, err
+		}
+
+		lastSeen[profileId] = seen
+	}
+
+	return lastSeen, rows.Err()
+}
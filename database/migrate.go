@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+	"wwfc/logging"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/logrusorgru/aurora/v3"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Arbitrary key shared by every backend so only one of them migrates at a time
+const migrationLockKey = 0x57574643
+
+const (
+	CreateMigrationsTable = `CREATE TABLE IF NOT EXISTS public.schema_migrations (version bigint NOT NULL PRIMARY KEY, name character varying NOT NULL, applied_at timestamp without time zone NOT NULL)`
+	GetAppliedMigrations  = `SELECT version, applied_at FROM schema_migrations ORDER BY version`
+	MigrationsTableExists = `SELECT to_regclass('public.schema_migrations') IS NOT NULL`
+	InsertMigration       = `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`
+	DeleteMigration       = `DELETE FROM schema_migrations WHERE version = $1`
+	LockMigrations        = `SELECT pg_advisory_lock($1)`
+	UnlockMigrations      = `SELECT pg_advisory_unlock($1)`
+)
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var (
+	ErrMigrationFileName   = errors.New("invalid migration file name")
+	ErrMigrationIncomplete = errors.New("migration is missing an up or down script")
+	ErrMigrationUnknown    = errors.New("database has a migration applied that this build does not know about")
+)
+
+var regexMigrationFile = regexp.MustCompile(`^([0-9]+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations returns the embedded migrations sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[uint64]*Migration{}
+	for _, entry := range entries {
+		match := regexMigrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, entry.Name())
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, entry.Name())
+		}
+
+		contents, err := fs.ReadFile(migrationFiles, "migrations/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration := migrations[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, entry.Name())
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	sorted := []Migration{}
+	for _, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMigrationIncomplete, migration.Version, migration.Name)
+		}
+
+		sorted = append(sorted, *migration)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return sorted, nil
+}
+
+// withMigrationLock runs fn on a single connection holding the migration advisory lock
+func withMigrationLock(pool *pgxpool.Pool, ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	// Advisory locks are held by the session, so the lock and the migrations must share a connection
+	_, err = conn.Exec(ctx, LockMigrations, migrationLockKey)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_, err := conn.Exec(context.Background(), UnlockMigrations, migrationLockKey)
+		if err != nil {
+			logging.Error("DATABASE", "Failed to release migration lock:", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, CreateMigrationsTable)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// migrationQuerier is satisfied by both the pool and a single connection from it
+type migrationQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func getAppliedMigrations(conn migrationQuerier, ctx context.Context) (map[uint64]time.Time, error) {
+	rows, err := conn.Query(ctx, GetAppliedMigrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[uint64]time.Time{}
+	for rows.Next() {
+		var version uint64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+
+		applied[version] = *fromLocalTimestamp(&appliedAt)
+	}
+
+	return applied, rows.Err()
+}
+
+// checkKnownMigrations refuses a database with migrations applied that are newer than this build
+func checkKnownMigrations(migrations []Migration, applied map[uint64]time.Time) error {
+	known := map[uint64]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+	}
+
+	for version := range applied {
+		if !known[version] {
+			return fmt.Errorf("%w: %04d", ErrMigrationUnknown, version)
+		}
+	}
+
+	return nil
+}
+
+func runMigrationScript(conn *pgxpool.Conn, ctx context.Context, script string, record func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	err = record(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MigrateUp applies every pending migration and returns how many were applied
+func MigrateUp(pool *pgxpool.Pool, ctx context.Context) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(pool, ctx, func(conn *pgxpool.Conn) error {
+		applied, err := getAppliedMigrations(conn, ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := checkKnownMigrations(migrations, applied); err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, exists := applied[migration.Version]; exists {
+				continue
+			}
+
+			logging.Notice("DATABASE", "Applying migration", aurora.Cyan(fmt.Sprintf("%04d_%s", migration.Version, migration.Name)))
+
+			err := runMigrationScript(conn, ctx, migration.Up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, InsertMigration, migration.Version, migration.Name, time.Now())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// MigrateDown reverts the given number of most recently applied migrations and returns how many were reverted
+func MigrateDown(pool *pgxpool.Pool, ctx context.Context, steps int) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(pool, ctx, func(conn *pgxpool.Conn) error {
+		applied, err := getAppliedMigrations(conn, ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := checkKnownMigrations(migrations, applied); err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := migrations[i]
+			if _, exists := applied[migration.Version]; !exists {
+				continue
+			}
+
+			logging.Notice("DATABASE", "Reverting migration", aurora.Cyan(fmt.Sprintf("%04d_%s", migration.Version, migration.Name)))
+
+			err := runMigrationScript(conn, ctx, migration.Down, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, DeleteMigration, migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// GetMigrationStatus returns every known migration along with whether it has been applied
+func GetMigrationStatus(pool *pgxpool.Pool, ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only reads, so there is no need to wait for a migration in progress
+	var exists bool
+	err = pool.QueryRow(ctx, MigrationsTableExists).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := map[uint64]time.Time{}
+	if exists {
+		applied, err = getAppliedMigrations(pool, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []MigrationStatus
+	for _, migration := range migrations {
+		appliedAt, exists := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			Applied:   exists,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != uint64(i+1) {
+			t.Errorf("expected migration %d, got %04d_%s", i+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestCheckKnownMigrations(t *testing.T) {
+	migrations := []Migration{{Version: 1}, {Version: 2}}
+
+	if err := checkKnownMigrations(migrations, map[uint64]time.Time{1: {}}); err != nil {
+		t.Error("expected known migrations to pass, got", err)
+	}
+
+	if err := checkKnownMigrations(migrations, map[uint64]time.Time{1: {}, 3: {}}); !errors.Is(err, ErrMigrationUnknown) {
+		t.Error("expected ErrMigrationUnknown, got", err)
+	}
+}
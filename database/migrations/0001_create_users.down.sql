@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.users;
+
+DROP SEQUENCE IF EXISTS public.users_profile_id_seq;
@@ -0,0 +1,23 @@
+CREATE SEQUENCE IF NOT EXISTS public.users_profile_id_seq
+    AS integer
+    START WITH 1000000000
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.users (
+    profile_id bigint NOT NULL DEFAULT nextval('public.users_profile_id_seq'::regclass),
+    user_id bigint NOT NULL,
+    gsbrcd character varying NOT NULL,
+    password character varying NOT NULL,
+    ng_device_id bigint,
+    email character varying NOT NULL,
+    unique_nick character varying NOT NULL,
+    firstname character varying,
+    lastname character varying DEFAULT ''::character varying,
+    mariokartwii_friend_info character varying,
+    CONSTRAINT users_pkey PRIMARY KEY (profile_id)
+);
+
+ALTER SEQUENCE public.users_profile_id_seq OWNED BY public.users.profile_id;
@@ -0,0 +1,11 @@
+ALTER TABLE ONLY public.users
+    DROP IF EXISTS last_ip_address,
+    DROP IF EXISTS last_ingamesn,
+    DROP IF EXISTS has_ban,
+    DROP IF EXISTS ban_issued,
+    DROP IF EXISTS ban_expires,
+    DROP IF EXISTS ban_reason,
+    DROP IF EXISTS ban_reason_hidden,
+    DROP IF EXISTS ban_moderator,
+    DROP IF EXISTS ban_tos,
+    DROP IF EXISTS open_host;
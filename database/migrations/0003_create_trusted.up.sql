@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS public.trusted (
+    id serial NOT NULL,
+    profile_id bigint,
+    CONSTRAINT trusted_pkey PRIMARY KEY (id)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.ban_appeals;
+
+DROP TABLE IF EXISTS public.bans;
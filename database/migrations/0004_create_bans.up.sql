@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS public.bans (
+    id bigserial NOT NULL,
+    profile_id bigint NOT NULL,
+    ng_device_id bigint,
+    ip_address character varying,
+    tos boolean NOT NULL,
+    moderator character varying NOT NULL,
+    reason character varying NOT NULL,
+    reason_hidden character varying,
+    created_at timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone,
+    revoked_at timestamp without time zone,
+    revoked_by character varying,
+    CONSTRAINT bans_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS bans_profile_id_idx ON public.bans (profile_id);
+CREATE INDEX IF NOT EXISTS bans_ng_device_id_idx ON public.bans (ng_device_id);
+CREATE INDEX IF NOT EXISTS bans_ip_address_idx ON public.bans (ip_address);
+
+CREATE TABLE IF NOT EXISTS public.ban_appeals (
+    id bigserial NOT NULL,
+    ban_id bigint NOT NULL REFERENCES public.bans (id) ON DELETE CASCADE,
+    author character varying NOT NULL,
+    note character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    CONSTRAINT ban_appeals_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS ban_appeals_ban_id_idx ON public.ban_appeals (ban_id);
+
+-- Carry over the single ban previously stored on each user
+INSERT INTO public.bans (profile_id, ng_device_id, ip_address, tos, moderator, reason, reason_hidden, created_at, expires_at, revoked_at, revoked_by)
+SELECT profile_id, ng_device_id, last_ip_address, COALESCE(ban_tos, false), COALESCE(ban_moderator, ''), COALESCE(ban_reason, ''), ban_reason_hidden, ban_issued, ban_expires,
+    CASE WHEN has_ban THEN NULL ELSE now() END,
+    CASE WHEN has_ban THEN NULL ELSE '' END
+FROM public.users
+WHERE ban_issued IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.moderators (
+    id serial NOT NULL,
+    name character varying NOT NULL,
+    role character varying NOT NULL,
+    token_hash character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    last_used_at timestamp without time zone,
+    CONSTRAINT moderators_pkey PRIMARY KEY (id),
+    CONSTRAINT moderators_name_key UNIQUE (name),
+    CONSTRAINT moderators_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT moderators_role_check CHECK (role IN ('viewer', 'moderator', 'admin'))
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS public.audit_log (
+    id bigserial NOT NULL,
+    moderator character varying NOT NULL,
+    action character varying NOT NULL,
+    profile_id bigint,
+    params jsonb NOT NULL DEFAULT '{}',
+    ip_address character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_moderator_idx ON public.audit_log (moderator);
+CREATE INDEX IF NOT EXISTS audit_log_profile_id_idx ON public.audit_log (profile_id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON public.audit_log (created_at);
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION public.audit_log_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON public.audit_log;
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON public.audit_log
+    FOR EACH ROW EXECUTE FUNCTION public.audit_log_reject_change();
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS public.friendships (
+    profile_id bigint NOT NULL,
+    friend_id bigint NOT NULL,
+    added_at timestamp without time zone NOT NULL,
+    authorized_at timestamp without time zone,
+    CONSTRAINT friendships_pkey PRIMARY KEY (profile_id, friend_id)
+);
+
+CREATE INDEX IF NOT EXISTS friendships_friend_id_idx ON public.friendships (friend_id);
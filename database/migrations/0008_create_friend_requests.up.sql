@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS public.friend_requests (
+    id bigserial NOT NULL,
+    from_profile_id bigint NOT NULL,
+    to_profile_id bigint NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    delivered_at timestamp without time zone,
+    CONSTRAINT friend_requests_pkey PRIMARY KEY (id),
+    CONSTRAINT friend_requests_pair_key UNIQUE (from_profile_id, to_profile_id)
+);
+
+CREATE INDEX IF NOT EXISTS friend_requests_to_profile_id_idx ON public.friend_requests (to_profile_id);
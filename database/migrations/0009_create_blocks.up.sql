@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS public.blocks (
+    profile_id bigint NOT NULL,
+    blocked_id bigint NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    CONSTRAINT blocks_pkey PRIMARY KEY (profile_id, blocked_id)
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.announcements (
+    id bigserial NOT NULL,
+    message character varying NOT NULL,
+    game_name character varying,
+    profile_id bigint,
+    send_at timestamp without time zone NOT NULL,
+    sent_at timestamp without time zone,
+    recipients integer,
+    created_by character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    CONSTRAINT announcements_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS announcements_pending_idx ON public.announcements (send_at) WHERE sent_at IS NULL;
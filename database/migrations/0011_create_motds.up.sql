@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public.motds (
+    id serial NOT NULL,
+    message character varying NOT NULL,
+    game_name character varying,
+    region smallint,
+    language smallint,
+    host_platform character varying,
+    starts_at timestamp without time zone,
+    ends_at timestamp without time zone,
+    priority integer NOT NULL DEFAULT 0,
+    created_by character varying NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    CONSTRAINT motds_pkey PRIMARY KEY (id)
+);
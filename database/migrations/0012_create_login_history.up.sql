@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS public.login_history (
+    profile_id bigint NOT NULL,
+    ng_device_id bigint NOT NULL,
+    ip_address character varying NOT NULL,
+    ingamesn character varying NOT NULL,
+    first_seen timestamp without time zone NOT NULL,
+    last_seen timestamp without time zone NOT NULL,
+    login_count integer NOT NULL DEFAULT 1,
+    CONSTRAINT login_history_pkey PRIMARY KEY (profile_id, ng_device_id, ip_address, ingamesn)
+);
+
+CREATE INDEX IF NOT EXISTS login_history_ng_device_id_idx ON public.login_history (ng_device_id);
+CREATE INDEX IF NOT EXISTS login_history_ip_address_idx ON public.login_history (ip_address);
+
+-- Seed the history with the last login known for each user
+INSERT INTO public.login_history (profile_id, ng_device_id, ip_address, ingamesn, first_seen, last_seen)
+SELECT profile_id, COALESCE(ng_device_id, 0), COALESCE(last_ip_address, ''), COALESCE(last_ingamesn, ''), now(), now()
+FROM public.users
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS public.login_sessions (
+    id bigserial NOT NULL,
+    profile_id bigint NOT NULL,
+    game_name character varying NOT NULL,
+    game_code character varying NOT NULL,
+    host_platform character varying NOT NULL,
+    ng_device_id bigint NOT NULL,
+    ip_address character varying NOT NULL,
+    login_at timestamp without time zone NOT NULL,
+    logout_at timestamp without time zone,
+    disconnect_reason character varying,
+    CONSTRAINT login_sessions_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS login_sessions_profile_id_idx ON public.login_sessions (profile_id, login_at);
+CREATE INDEX IF NOT EXISTS login_sessions_open_idx ON public.login_sessions (id) WHERE logout_at IS NULL;
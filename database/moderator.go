@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertModerator         = `INSERT INTO moderators (name, role, token_hash, created_at) VALUES ($1, $2, $3, $4)`
+	GetModeratorByTokenHash = `UPDATE moderators SET last_used_at = $2 WHERE token_hash = $1 RETURNING id, name, role, created_at, last_used_at`
+	GetModeratorList        = `SELECT id, name, role, created_at, last_used_at FROM moderators ORDER BY name`
+	UpdateModeratorRole     = `UPDATE moderators SET role = $2 WHERE name = $1`
+	UpdateModeratorToken    = `UPDATE moderators SET token_hash = $2 WHERE name = $1`
+	DeleteModeratorByName   = `DELETE FROM moderators WHERE name = $1`
+	DoesModeratorExist      = `SELECT EXISTS(SELECT 1 FROM moderators WHERE name = $1)`
+)
+
+const (
+	RoleViewer    = "viewer"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+type Moderator struct {
+	ModeratorId int        `json:"id"`
+	Name        string     `json:"name"`
+	Role        string     `json:"role"`
+	CreatedAt   time.Time  `json:"created"`
+	LastUsedAt  *time.Time `json:"last_used"`
+}
+
+var (
+	ErrModeratorNotFound = errors.New("moderator does not exist")
+	ErrModeratorExists   = errors.New("moderator already exists")
+	ErrInvalidRole       = errors.New("invalid moderator role")
+)
+
+func IsValidRole(role string) bool {
+	return role == RoleViewer || role == RoleModerator || role == RoleAdmin
+}
+
+// Tokens are long random strings, so a plain SHA-256 is enough to keep them out of the database
+func hashModeratorToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func generateModeratorToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// CreateModerator adds a moderator account and returns its API token. The token cannot be recovered later.
+func CreateModerator(pool *pgxpool.Pool, ctx context.Context, name string, role string) (string, error) {
+	if !IsValidRole(role) {
+		return "", ErrInvalidRole
+	}
+
+	var exists bool
+	err := pool.QueryRow(ctx, DoesModeratorExist, name).Scan(&exists)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		return "", ErrModeratorExists
+	}
+
+	token, err := generateModeratorToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = pool.Exec(ctx, InsertModerator, name, role, hashModeratorToken(token), time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// AuthenticateModerator looks up the moderator owning the API token
+func AuthenticateModerator(pool *pgxpool.Pool, ctx context.Context, token string) (Moderator, error) {
+	var moderator Moderator
+	err := pool.QueryRow(ctx, GetModeratorByTokenHash, hashModeratorToken(token), time.Now()).Scan(&moderator.ModeratorId, &moderator.Name, &moderator.Role, &moderator.CreatedAt, &moderator.LastUsedAt)
+	if err == pgx.ErrNoRows {
+		return Moderator{}, ErrModeratorNotFound
+	}
+
+	return moderator, err
+}
+
+func GetModerators(pool *pgxpool.Pool, ctx context.Context) ([]Moderator, error) {
+	rows, err := pool.Query(ctx, GetModeratorList)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	moderators := []Moderator{}
+	for rows.Next() {
+		var moderator Moderator
+		err := rows.Scan(&moderator.ModeratorId, &moderator.Name, &moderator.Role, &moderator.CreatedAt, &moderator.LastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		moderators = append(moderators, moderator)
+	}
+
+	return moderators, rows.Err()
+}
+
+func SetModeratorRole(pool *pgxpool.Pool, ctx context.Context, name string, role string) error {
+	if !IsValidRole(role) {
+		return ErrInvalidRole
+	}
+
+	tag, err := pool.Exec(ctx, UpdateModeratorRole, name, role)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrModeratorNotFound
+	}
+
+	return nil
+}
+
+// ResetModeratorToken replaces the moderator's API token and returns the new one
+func ResetModeratorToken(pool *pgxpool.Pool, ctx context.Context, name string) (string, error) {
+	token, err := generateModeratorToken()
+	if err != nil {
+		return "", err
+	}
+
+	tag, err := pool.Exec(ctx, UpdateModeratorToken, name, hashModeratorToken(token))
+	if err != nil {
+		return "", err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return "", ErrModeratorNotFound
+	}
+
+	return token, nil
+}
+
+func DeleteModerator(pool *pgxpool.Pool, ctx context.Context, name string) error {
+	tag, err := pool.Exec(ctx, DeleteModeratorByName, name)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrModeratorNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	InsertMotd        = `INSERT INTO motds (message, game_name, region, language, host_platform, starts_at, ends_at, priority, created_by, created_at) VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10) RETURNING id`
+	GetMotdList       = `SELECT id, message, COALESCE(game_name, ''), region, language, COALESCE(host_platform, ''), starts_at, ends_at, priority, created_by, created_at FROM motds WHERE ends_at IS NULL OR ends_at > $1 ORDER BY priority DESC, id DESC`
+	GetActiveMotdList = `SELECT id, message, COALESCE(game_name, ''), region, language, COALESCE(host_platform, ''), starts_at, ends_at, priority, created_by, created_at FROM motds WHERE (starts_at IS NULL OR starts_at <= $1) AND (ends_at IS NULL OR ends_at > $1) ORDER BY priority DESC, id DESC`
+	DeleteMotdById    = `DELETE FROM motds WHERE id = $1`
+)
+
+// Motd is a message of the day sent at login. Empty or nil targeting fields match every player.
+type Motd struct {
+	MotdId       int        `json:"id"`
+	Message      string     `json:"message"`
+	GameName     string     `json:"game,omitempty"`
+	Region       *byte      `json:"region"`
+	Language     *byte      `json:"language"`
+	HostPlatform string     `json:"host_platform,omitempty"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Priority     int        `json:"priority"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created"`
+}
+
+var ErrMotdNotFound = errors.New("message of the day does not exist")
+
+// Matches checks the entry's targeting against a player
+func (motd Motd) Matches(gameName string, region byte, language byte, hostPlatform string) bool {
+	return (motd.GameName == "" || motd.GameName == gameName) &&
+		(motd.Region == nil || *motd.Region == region) &&
+		(motd.Language == nil || *motd.Language == language) &&
+		(motd.HostPlatform == "" || motd.HostPlatform == hostPlatform)
+}
+
+func AddMotd(pool *pgxpool.Pool, ctx context.Context, motd Motd) (int, error) {
+	var region, language *int16
+	if motd.Region != nil {
+		value := int16(*motd.Region)
+		region = &value
+	}
+
+	if motd.Language != nil {
+		value := int16(*motd.Language)
+		language = &value
+	}
+
+	var motdId int
+	err := pool.QueryRow(ctx, InsertMotd, motd.Message, motd.GameName, region, language, motd.HostPlatform, motd.StartsAt, motd.EndsAt, motd.Priority, motd.CreatedBy, time.Now()).Scan(&motdId)
+	return motdId, err
+}
+
+// GetMotds returns every entry that hasn't ended yet, highest priority first
+func GetMotds(pool *pgxpool.Pool, ctx context.Context) ([]Motd, error) {
+	return queryMotds(pool, ctx, GetMotdList)
+}
+
+// GetActiveMotds returns the entries that should be shown now, highest priority first. The window is
+// checked in SQL because the timestamps are stored as local time without a time zone.
+func GetActiveMotds(pool *pgxpool.Pool, ctx context.Context) ([]Motd, error) {
+	return queryMotds(pool, ctx, GetActiveMotdList)
+}
+
+func queryMotds(pool *pgxpool.Pool, ctx context.Context, query string) ([]Motd, error) {
+	rows, err := pool.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	motds := []Motd{}
+	for rows.Next() {
+		var motd Motd
+		var region, language *int16
+		err := rows.Scan(&motd.MotdId, &motd.Message, &motd.GameName, &region, &language, &motd.HostPlatform, &motd.StartsAt, &motd.EndsAt, &motd.Priority, &motd.CreatedBy, &motd.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if region != nil {
+			value := byte(*region)
+			motd.Region = &value
+		}
+
+		if language != nil {
+			value := byte(*language)
+			motd.Language = &value
+		}
+
+		motds = append(motds, motd)
+	}
+
+	return motds, rows.Err()
+}
+
+func DeleteMotd(pool *pgxpool.Pool, ctx context.Context, motdId int) error {
+	result, err := pool.Exec(ctx, DeleteMotdById, motdId)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrMotdNotFound
+	}
+
+	return nil
+}
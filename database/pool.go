@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+	"wwfc/common"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Connect creates the connection pool shared by every server module, using the database settings from config.xml
+func Connect(ctx context.Context, config common.Config) (*pgxpool.Pool, error) {
+	dbUrl := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(config.Username, config.Password),
+		Host:   config.DatabaseAddress,
+		Path:   "/" + config.DatabaseName,
+	}
+
+	query := url.Values{}
+	if config.DatabaseSSLMode != "" {
+		query.Set("sslmode", config.DatabaseSSLMode)
+	}
+	if config.DatabaseSSLRootCert != "" {
+		query.Set("sslrootcert", config.DatabaseSSLRootCert)
+	}
+	dbUrl.RawQuery = query.Encode()
+
+	dbConf, err := pgxpool.ParseConfig(dbUrl.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DatabaseMaxConns > 0 {
+		dbConf.MaxConns = config.DatabaseMaxConns
+	}
+
+	if config.DatabaseStatementTimeout > 0 {
+		dbConf.ConnConfig.RuntimeParams["statement_timeout"] = strconv.Itoa(config.DatabaseStatementTimeout)
+	}
+
+	if config.DatabaseHealthCheckPeriod > 0 {
+		dbConf.HealthCheckPeriod = time.Duration(config.DatabaseHealthCheckPeriod) * time.Second
+	}
+
+	pool, err := pgxpool.ConnectConfig(ctx, dbConf)
+	if err != nil {
+		return nil, err
+	}
+
+	// ConnectConfig connects lazily, so make sure the database is actually reachable
+	pingCtx, release := context.WithTimeout(ctx, 10*time.Second)
+	defer release()
+
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// UserRepository covers the users and trusted tables
+type UserRepository interface {
+	LoginUserToGPCM(ctx context.Context, userId uint64, gsbrcd string, profileId uint32, ngDeviceId uint32, ipAddress string, ingamesn string) (User, error)
+	LoginUserToGameStats(ctx context.Context, userId uint64, gsbrcd string) (User, error)
+	GetProfile(ctx context.Context, profileId uint32) (User, bool)
+	UpdateProfile(ctx context.Context, user *User, data map[string]string)
+	GetUserProfiles(ctx context.Context, userId uint64) ([]User, error)
+
+	DoesUserTrusted(ctx context.Context, profileId uint32) (bool, error)
+	AddTrusted(ctx context.Context, profileId uint32) (bool, error)
+	RemoveTrusted(ctx context.Context, profileId uint32) bool
+	FetchTrusted(ctx context.Context) ([]uint32, error)
+}
+
+// BanRepository covers the bans and ban_appeals tables
+type BanRepository interface {
+	BanUser(ctx context.Context, profileId uint32, tos bool, length time.Duration, reason string, reasonHidden string, moderator string) bool
+	UnbanUser(ctx context.Context, profileId uint32, moderator string) bool
+	GetBanHistory(ctx context.Context, profileId uint32) ([]Ban, error)
+	UpdateBanExpiry(ctx context.Context, banId int64, expires time.Time) (uint32, error)
+	AddBanAppeal(ctx context.Context, banId int64, author string, note string) (uint32, error)
+	GetActiveBans(ctx context.Context, profileIds []uint32, ngDeviceIds []uint32) ([]Ban, error)
+}
+
+// LinkRepository covers the login_history table used to find accounts sharing a device or IP address
+type LinkRepository interface {
+	GetLoginHistory(ctx context.Context, profileIds []uint32) ([]LoginRecord, error)
+	FindLinkedLogins(ctx context.Context, ngDeviceIds []uint32, ipAddresses []string, limit int) ([]LoginRecord, error)
+}
+
+// FriendInfoRepository covers the per-game friend info blobs stored through SAKE
+type FriendInfoRepository interface {
+	GetMKWFriendInfo(ctx context.Context, profileId uint32) string
+	UpdateMKWFriendInfo(ctx context.Context, profileId uint32, info string)
+}
+
+// FriendRepository covers the friendships, friend_requests and blocks tables backing GPCM buddy lists
+type FriendRepository interface {
+	AddFriend(ctx context.Context, profileId uint32, friendId uint32) error
+	AuthorizeFriendship(ctx context.Context, profileId uint32, friendId uint32) error
+	RemoveFriend(ctx context.Context, profileId uint32, friendId uint32) error
+	GetFriends(ctx context.Context, profileId uint32) ([]Friendship, error)
+	IsFriendAuthorized(ctx context.Context, profileId uint32, friendId uint32) (bool, error)
+
+	AddFriendRequest(ctx context.Context, fromProfileId uint32, toProfileId uint32, length time.Duration) error
+	GetFriendRequests(ctx context.Context, profileId uint32) ([]FriendRequest, error)
+	MarkFriendRequestDelivered(ctx context.Context, fromProfileId uint32, toProfileId uint32) (bool, error)
+	DeleteFriendRequest(ctx context.Context, fromProfileId uint32, toProfileId uint32) error
+	DeleteExpiredFriendRequests(ctx context.Context) (int64, error)
+
+	AddBlock(ctx context.Context, profileId uint32, blockedId uint32) (bool, error)
+	RemoveBlock(ctx context.Context, profileId uint32, blockedId uint32) (bool, error)
+	GetBlocks(ctx context.Context, profileId uint32) ([]uint32, error)
+}
+
+// AnnouncementRepository covers the announcements pushed to players over GPCM
+type AnnouncementRepository interface {
+	AddAnnouncement(ctx context.Context, message string, gameName string, profileId uint32, sendAt time.Time, createdBy string) (int64, error)
+	ClaimDueAnnouncements(ctx context.Context) ([]Announcement, error)
+	SetAnnouncementRecipients(ctx context.Context, announcementId int64, recipients int) error
+	GetAnnouncements(ctx context.Context, sentAfter time.Time) ([]Announcement, error)
+	CancelAnnouncement(ctx context.Context, announcementId int64) error
+}
+
+// MotdRepository covers the messages of the day sent at GPCM login
+type MotdRepository interface {
+	AddMotd(ctx context.Context, motd Motd) (int, error)
+	GetMotds(ctx context.Context) ([]Motd, error)
+	GetActiveMotds(ctx context.Context) ([]Motd, error)
+	DeleteMotd(ctx context.Context, motdId int) error
+}
+
+// LoginSessionRepository covers the login_sessions table recording each GPCM login
+type LoginSessionRepository interface {
+	StartLoginSession(ctx context.Context, session LoginSession) (int64, error)
+	EndLoginSession(ctx context.Context, sessionId int64, reason string) error
+	CloseOpenLoginSessions(ctx context.Context, reason string) (int64, error)
+	GetLoginSessions(ctx context.Context, profileId uint32, limit int) ([]LoginSession, error)
+	GetPlaytime(ctx context.Context, profileId uint32) (time.Duration, error)
+	GetLastSeen(ctx context.Context, profileIds []uint32) (map[uint32]time.Time, error)
+}
+
+// ModeratorRepository covers the moderator accounts used by the admin API
+type ModeratorRepository interface {
+	CreateModerator(ctx context.Context, name string, role string) (string, error)
+	AuthenticateModerator(ctx context.Context, token string) (Moderator, error)
+	GetModerators(ctx context.Context) ([]Moderator, error)
+	SetModeratorRole(ctx context.Context, name string, role string) error
+	ResetModeratorToken(ctx context.Context, name string) (string, error)
+	DeleteModerator(ctx context.Context, name string) error
+}
+
+// AuditRepository covers the append-only moderation audit log
+type AuditRepository interface {
+	AddAuditEntry(ctx context.Context, moderator string, action string, profileId uint32, params map[string]string, ipAddress string) error
+	GetAuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error)
+}
+
+// PostgresRepository implements every repository interface on top of the shared pool
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+func (r *PostgresRepository) LoginUserToGPCM(ctx context.Context, userId uint64, gsbrcd string, profileId uint32, ngDeviceId uint32, ipAddress string, ingamesn string) (User, error) {
+	return LoginUserToGPCM(r.pool, ctx, userId, gsbrcd, profileId, ngDeviceId, ipAddress, ingamesn)
+}
+
+func (r *PostgresRepository) LoginUserToGameStats(ctx context.Context, userId uint64, gsbrcd string) (User, error) {
+	return LoginUserToGameStats(r.pool, ctx, userId, gsbrcd)
+}
+
+func (r *PostgresRepository) GetProfile(ctx context.Context, profileId uint32) (User, bool) {
+	return GetProfile(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) UpdateProfile(ctx context.Context, user *User, data map[string]string) {
+	user.UpdateProfile(r.pool, ctx, data)
+}
+
+func (r *PostgresRepository) GetUserProfiles(ctx context.Context, userId uint64) ([]User, error) {
+	return GetUserProfiles(r.pool, ctx, userId)
+}
+
+func (r *PostgresRepository) DoesUserTrusted(ctx context.Context, profileId uint32) (bool, error) {
+	return DoesUserTrusted(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) AddTrusted(ctx context.Context, profileId uint32) (bool, error) {
+	return AddTrusted(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) RemoveTrusted(ctx context.Context, profileId uint32) bool {
+	return RemoveTrusted(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) FetchTrusted(ctx context.Context) ([]uint32, error) {
+	return FetchTrusted(r.pool, ctx)
+}
+
+func (r *PostgresRepository) BanUser(ctx context.Context, profileId uint32, tos bool, length time.Duration, reason string, reasonHidden string, moderator string) bool {
+	return BanUser(r.pool, ctx, profileId, tos, length, reason, reasonHidden, moderator)
+}
+
+func (r *PostgresRepository) UnbanUser(ctx context.Context, profileId uint32, moderator string) bool {
+	return UnbanUser(r.pool, ctx, profileId, moderator)
+}
+
+func (r *PostgresRepository) GetBanHistory(ctx context.Context, profileId uint32) ([]Ban, error) {
+	return GetBanHistory(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) UpdateBanExpiry(ctx context.Context, banId int64, expires time.Time) (uint32, error) {
+	return UpdateBanExpiry(r.pool, ctx, banId, expires)
+}
+
+func (r *PostgresRepository) AddBanAppeal(ctx context.Context, banId int64, author string, note string) (uint32, error) {
+	return AddBanAppeal(r.pool, ctx, banId, author, note)
+}
+
+func (r *PostgresRepository) GetActiveBans(ctx context.Context, profileIds []uint32, ngDeviceIds []uint32) ([]Ban, error) {
+	return GetActiveBans(r.pool, ctx, profileIds, ngDeviceIds)
+}
+
+func (r *PostgresRepository) GetMKWFriendInfo(ctx context.Context, profileId uint32) string {
+	return GetMKWFriendInfo(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) UpdateMKWFriendInfo(ctx context.Context, profileId uint32, info string) {
+	UpdateMKWFriendInfo(r.pool, ctx, profileId, info)
+}
+
+func (r *PostgresRepository) CreateModerator(ctx context.Context, name string, role string) (string, error) {
+	return CreateModerator(r.pool, ctx, name, role)
+}
+
+func (r *PostgresRepository) AuthenticateModerator(ctx context.Context, token string) (Moderator, error) {
+	return AuthenticateModerator(r.pool, ctx, token)
+}
+
+func (r *PostgresRepository) GetModerators(ctx context.Context) ([]Moderator, error) {
+	return GetModerators(r.pool, ctx)
+}
+
+func (r *PostgresRepository) SetModeratorRole(ctx context.Context, name string, role string) error {
+	return SetModeratorRole(r.pool, ctx, name, role)
+}
+
+func (r *PostgresRepository) ResetModeratorToken(ctx context.Context, name string) (string, error) {
+	return ResetModeratorToken(r.pool, ctx, name)
+}
+
+func (r *PostgresRepository) DeleteModerator(ctx context.Context, name string) error {
+	return DeleteModerator(r.pool, ctx, name)
+}
+
+func (r *PostgresRepository) AddAuditEntry(ctx context.Context, moderator string, action string, profileId uint32, params map[string]string, ipAddress string) error {
+	return AddAuditEntry(r.pool, ctx, moderator, action, profileId, params, ipAddress)
+}
+
+func (r *PostgresRepository) GetAuditLog(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	return GetAuditLog(r.pool, ctx, filter)
+}
+
+func (r *PostgresRepository) AddFriend(ctx context.Context, profileId uint32, friendId uint32) error {
+	return AddFriend(r.pool, ctx, profileId, friendId)
+}
+
+func (r *PostgresRepository) AuthorizeFriendship(ctx context.Context, profileId uint32, friendId uint32) error {
+	return AuthorizeFriendship(r.pool, ctx, profileId, friendId)
+}
+
+func (r *PostgresRepository) RemoveFriend(ctx context.Context, profileId uint32, friendId uint32) error {
+	return RemoveFriend(r.pool, ctx, profileId, friendId)
+}
+
+func (r *PostgresRepository) GetFriends(ctx context.Context, profileId uint32) ([]Friendship, error) {
+	return GetFriends(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) IsFriendAuthorized(ctx context.Context, profileId uint32, friendId uint32) (bool, error) {
+	return IsFriendAuthorized(r.pool, ctx, profileId, friendId)
+}
+
+func (r *PostgresRepository) AddFriendRequest(ctx context.Context, fromProfileId uint32, toProfileId uint32, length time.Duration) error {
+	return AddFriendRequest(r.pool, ctx, fromProfileId, toProfileId, length)
+}
+
+func (r *PostgresRepository) GetFriendRequests(ctx context.Context, profileId uint32) ([]FriendRequest, error) {
+	return GetFriendRequests(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) MarkFriendRequestDelivered(ctx context.Context, fromProfileId uint32, toProfileId uint32) (bool, error) {
+	return MarkFriendRequestDelivered(r.pool, ctx, fromProfileId, toProfileId)
+}
+
+func (r *PostgresRepository) DeleteFriendRequest(ctx context.Context, fromProfileId uint32, toProfileId uint32) error {
+	return DeleteFriendRequest(r.pool, ctx, fromProfileId, toProfileId)
+}
+
+func (r *PostgresRepository) DeleteExpiredFriendRequests(ctx context.Context) (int64, error) {
+	return DeleteExpiredFriendRequests(r.pool, ctx)
+}
+
+func (r *PostgresRepository) AddBlock(ctx context.Context, profileId uint32, blockedId uint32) (bool, error) {
+	return AddBlock(r.pool, ctx, profileId, blockedId)
+}
+
+func (r *PostgresRepository) RemoveBlock(ctx context.Context, profileId uint32, blockedId uint32) (bool, error) {
+	return RemoveBlock(r.pool, ctx, profileId, blockedId)
+}
+
+func (r *PostgresRepository) GetBlocks(ctx context.Context, profileId uint32) ([]uint32, error) {
+	return GetBlocks(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) AddAnnouncement(ctx context.Context, message string, gameName string, profileId uint32, sendAt time.Time, createdBy string) (int64, error) {
+	return AddAnnouncement(r.pool, ctx, message, gameName, profileId, sendAt, createdBy)
+}
+
+func (r *PostgresRepository) ClaimDueAnnouncements(ctx context.Context) ([]Announcement, error) {
+	return ClaimDueAnnouncements(r.pool, ctx)
+}
+
+func (r *PostgresRepository) SetAnnouncementRecipients(ctx context.Context, announcementId int64, recipients int) error {
+	return SetAnnouncementRecipients(r.pool, ctx, announcementId, recipients)
+}
+
+func (r *PostgresRepository) GetAnnouncements(ctx context.Context, sentAfter time.Time) ([]Announcement, error) {
+	return GetAnnouncements(r.pool, ctx, sentAfter)
+}
+
+func (r *PostgresRepository) CancelAnnouncement(ctx context.Context, announcementId int64) error {
+	return CancelAnnouncement(r.pool, ctx, announcementId)
+}
+
+func (r *PostgresRepository) AddMotd(ctx context.Context, motd Motd) (int, error) {
+	return AddMotd(r.pool, ctx, motd)
+}
+
+func (r *PostgresRepository) GetMotds(ctx context.Context) ([]Motd, error) {
+	return GetMotds(r.pool, ctx)
+}
+
+func (r *PostgresRepository) GetActiveMotds(ctx context.Context) ([]Motd, error) {
+	return GetActiveMotds(r.pool, ctx)
+}
+
+func (r *PostgresRepository) DeleteMotd(ctx context.Context, motdId int) error {
+	return DeleteMotd(r.pool, ctx, motdId)
+}
+
+func (r *PostgresRepository) GetLoginHistory(ctx context.Context, profileIds []uint32) ([]LoginRecord, error) {
+	return GetLoginHistory(r.pool, ctx, profileIds)
+}
+
+func (r *PostgresRepository) FindLinkedLogins(ctx context.Context, ngDeviceIds []uint32, ipAddresses []string, limit int) ([]LoginRecord, error) {
+	return FindLinkedLogins(r.pool, ctx, ngDeviceIds, ipAddresses, limit)
+}
+
+func (r *PostgresRepository) StartLoginSession(ctx context.Context, session LoginSession) (int64, error) {
+	return StartLoginSession(r.pool, ctx, session)
+}
+
+func (r *PostgresRepository) EndLoginSession(ctx context.Context, sessionId int64, reason string) error {
+	return EndLoginSession(r.pool, ctx, sessionId, reason)
+}
+
+func (r *PostgresRepository) CloseOpenLoginSessions(ctx context.Context, reason string) (int64, error) {
+	return CloseOpenLoginSessions(r.pool, ctx, reason)
+}
+
+func (r *PostgresRepository) GetLoginSessions(ctx context.Context, profileId uint32, limit int) ([]LoginSession, error) {
+	return GetLoginSessions(r.pool, ctx, profileId, limit)
+}
+
+func (r *PostgresRepository) GetPlaytime(ctx context.Context, profileId uint32) (time.Duration, error) {
+	return GetPlaytime(r.pool, ctx, profileId)
+}
+
+func (r *PostgresRepository) GetLastSeen(ctx context.Context, profileIds []uint32) (map[uint32]time.Time, error) {
+	return GetLastSeen(r.pool, ctx, profileIds)
+}
@@ -0,0 +1,26 @@
+package database
+
+import "time"
+
+// Timestamps are stored as the server's local time in columns without a time zone, and pgx reads
+// them back labelled as UTC. fromLocalTimestamp restores the local time zone so the value can be
+// compared with time.Now() or shown in another zone.
+func fromLocalTimestamp(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	return &local
+}
+
+// toLocalTimestamp converts a time to the server's local time zone before it is compared with a column
+// without a time zone, as pgx sends the time as it reads in its own zone
+func toLocalTimestamp(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	local := t.In(time.Local)
+	return &local
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalTimestamps(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+9", 9*60*60)
+	defer func() { time.Local = local }()
+
+	// 12:00 local time, read back by pgx labelled as UTC
+	stored := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if got, want := fromLocalTimestamp(&stored).UTC(), time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("fromLocalTimestamp() = %v, expected %v", got, want)
+	}
+
+	// A filter given in UTC is compared against local clock times
+	filter := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	if got := toLocalTimestamp(&filter); got.Hour() != 12 || !got.Equal(filter) {
+		t.Errorf("toLocalTimestamp() = %v, expected 12:00 local time", got)
+	}
+
+	if fromLocalTimestamp(nil) != nil || toLocalTimestamp(nil) != nil {
+		t.Error("nil timestamps should stay nil")
+	}
+}
@@ -16,15 +16,13 @@ const (
 	UpdateUserTable         = `UPDATE users SET firstname = CASE WHEN $3 THEN $2 ELSE firstname END, lastname = CASE WHEN $5 THEN $4 ELSE lastname END, open_host = CASE WHEN $7 THEN $6 ELSE open_host END WHERE profile_id = $1`
 	UpdateUserProfileID     = `UPDATE users SET profile_id = $3 WHERE user_id = $1 AND gsbrcd = $2`
 	UpdateUserNGDeviceID    = `UPDATE users SET ng_device_id = $2 WHERE profile_id = $1`
-	GetUser                 = `SELECT user_id, gsbrcd, email, unique_nick, firstname, lastname, open_host FROM users WHERE profile_id = $1`
+	GetUser                 = `SELECT user_id, gsbrcd, COALESCE(ng_device_id, 0), email, unique_nick, firstname, lastname, open_host, COALESCE(last_ip_address, ''), COALESCE(last_ingamesn, '') FROM users WHERE profile_id = $1`
+	GetUserProfileList      = `SELECT profile_id, gsbrcd FROM users WHERE user_id = $1 ORDER BY profile_id`
 	DoesUserExist           = `SELECT EXISTS(SELECT 1 FROM users WHERE user_id = $1 AND gsbrcd = $2)`
 	IsProfileIDInUse        = `SELECT EXISTS(SELECT 1 FROM users WHERE profile_id = $1)`
 	DeleteUserSession       = `DELETE FROM sessions WHERE profile_id = $1`
 	GetUserProfileID        = `SELECT profile_id, ng_device_id, email, unique_nick, firstname, lastname, open_host FROM users WHERE user_id = $1 AND gsbrcd = $2`
 	UpdateUserLastIPAddress = `UPDATE users SET last_ip_address = $2, last_ingamesn = $3 WHERE profile_id = $1`
-	UpdateUserBan           = `UPDATE users SET has_ban = true, ban_issued = $2, ban_expires = $3, ban_reason = $4, ban_reason_hidden = $5, ban_moderator = $6, ban_tos = $7 WHERE profile_id = $1`
-	SearchUserBan           = `SELECT has_ban, ban_tos, ng_device_id FROM users WHERE has_ban = true AND (profile_id = $1 OR ng_device_id = $2 OR last_ip_address = $3) AND (ban_expires IS NULL OR ban_expires > $4) ORDER BY ban_tos DESC LIMIT 1`
-	DisableUserBan          = `UPDATE users SET has_ban = false WHERE profile_id = $1`
 
 	GetMKWFriendInfoQuery    = `SELECT mariokartwii_friend_info FROM users WHERE profile_id = $1`
 	UpdateMKWFriendInfoQuery = `UPDATE users SET mariokartwii_friend_info = $2 WHERE profile_id = $1`
@@ -50,6 +48,12 @@ type User struct {
 	OpenHost           bool
 	Trusted            bool
 	CTGPVER            string
+	// Only filled in by GetProfile
+	LastIPAddress string
+	LastInGameSN  string
+	// Only filled in by LoginUserToGPCM, from the active ban shown to the player
+	BanReason  string
+	BanExpires *time.Time
 }
 
 var (
@@ -147,7 +151,7 @@ func (user *User) UpdateProfile(pool *pgxpool.Pool, ctx context.Context, data ma
 func GetProfile(pool *pgxpool.Pool, ctx context.Context, profileId uint32) (User, bool) {
 	user := User{}
 	row := pool.QueryRow(ctx, GetUser, profileId)
-	err := row.Scan(&user.UserId, &user.GsbrCode, &user.Email, &user.UniqueNick, &user.FirstName, &user.LastName, &user.OpenHost)
+	err := row.Scan(&user.UserId, &user.GsbrCode, &user.NgDeviceId, &user.Email, &user.UniqueNick, &user.FirstName, &user.LastName, &user.OpenHost, &user.LastIPAddress, &user.LastInGameSN)
 	if err != nil {
 		return User{}, false
 	}
@@ -156,10 +160,28 @@ func GetProfile(pool *pgxpool.Pool, ctx context.Context, profileId uint32) (User
 	return user, true
 }
 
-func BanUser(pool *pgxpool.Pool, ctx context.Context, profileId uint32, tos bool, length time.Duration, reason string, reasonHidden string, moderator string) bool {
-	_, err := pool.Exec(ctx, UpdateUserBan, profileId, time.Now(), time.Now().Add(length), reason, reasonHidden, moderator, tos)
-	return err == nil
+// GetUserProfiles returns the profile ID and gsbrcd of every profile created by the user ID, one per game
+func GetUserProfiles(pool *pgxpool.Pool, ctx context.Context, userId uint64) ([]User, error) {
+	rows, err := pool.Query(ctx, GetUserProfileList, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []User{}
+	for rows.Next() {
+		user := User{UserId: userId}
+		err := rows.Scan(&user.ProfileId, &user.GsbrCode)
+		if err != nil {
+			return nil, err
+		}
+
+		profiles = append(profiles, user)
+	}
+
+	return profiles, rows.Err()
 }
+
 func DoesUserTrusted(pool *pgxpool.Pool, ctx context.Context, profileID uint32) (bool, error) {
 	var trusted bool
 	err := pool.QueryRow(ctx, DoesUserExistTrusted, profileID).Scan(&trusted)
@@ -208,11 +230,6 @@ func RemoveTrusted(pool *pgxpool.Pool, ctx context.Context, profileId uint32) bo
 	return err == nil
 }
 
-func UnbanUser(pool *pgxpool.Pool, ctx context.Context, profileId uint32) bool {
-	_, err := pool.Exec(ctx, DisableUserBan, profileId)
-	return err == nil
-}
-
 func GetMKWFriendInfo(pool *pgxpool.Pool, ctx context.Context, profileId uint32) string {
 	var info string
 	err := pool.QueryRow(ctx, GetMKWFriendInfoQuery, profileId).Scan(&info)
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	GroupCreated   = "group_created"
+	GroupDeleted   = "group_deleted"
+	PlayerJoined   = "player_joined"
+	PlayerLeft     = "player_left"
+	HostChanged    = "host_changed"
+	SuspendChanged = "suspend_changed"
+	PlayerLogin    = "player_login"
+	PlayerLogout   = "player_logout"
+)
+
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Game string      `json:"game,omitempty"`
+	Data interface{} `json:"data"`
+}
+
+type GroupData struct {
+	GroupName string `json:"group"`
+	HostPID   string `json:"host_pid,omitempty"`
+}
+
+type GroupPlayerData struct {
+	GroupName string `json:"group"`
+	PID       string `json:"pid"`
+	JoinIndex string `json:"join_index"`
+}
+
+type SuspendData struct {
+	GroupName string `json:"group"`
+	PID       string `json:"pid"`
+	Suspend   bool   `json:"suspend"`
+}
+
+type LoginData struct {
+	PID        uint32 `json:"pid"`
+	GameCode   string `json:"game_code,omitempty"`
+	InGameName string `json:"name,omitempty"`
+}
+
+// Subscriber receives every published event on C until it is closed.
+// Events are dropped rather than blocking the publisher when C is full.
+type Subscriber struct {
+	C       chan Event
+	dropped atomic.Bool
+}
+
+var (
+	mutex       = sync.Mutex{}
+	subscribers = map[*Subscriber]bool{}
+	lastEventID atomic.Uint64
+)
+
+// Publish sends an event to every subscriber without blocking.
+// It is safe to call while holding the qr2 or gpcm mutex.
+func Publish(eventType string, game string, data interface{}) {
+	event := Event{
+		ID:   lastEventID.Add(1),
+		Type: eventType,
+		Time: time.Now(),
+		Game: game,
+		Data: data,
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for subscriber := range subscribers {
+		select {
+		case subscriber.C <- event:
+		default:
+			subscriber.dropped.Store(true)
+		}
+	}
+}
+
+func Subscribe(bufferSize int) *Subscriber {
+	subscriber := &Subscriber{C: make(chan Event, bufferSize)}
+
+	mutex.Lock()
+	subscribers[subscriber] = true
+	mutex.Unlock()
+
+	return subscriber
+}
+
+// Unsubscribe stops delivering events to the subscriber and closes its channel
+func (s *Subscriber) Unsubscribe() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if subscribers[s] {
+		delete(subscribers, s)
+		close(s.C)
+	}
+}
+
+// Dropped reports whether events were dropped since the last call, clearing the flag
+func (s *Subscriber) Dropped() bool {
+	return s.dropped.Swap(false)
+}
+
+// CloseAll unsubscribes everyone, ending their streams
+func CloseAll() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for subscriber := range subscribers {
+		close(subscriber.C)
+	}
+
+	subscribers = map[*Subscriber]bool{}
+}
@@ -2,16 +2,12 @@ package gamestats
 
 import (
 	"context"
-	"encoding/gob"
-	"fmt"
-	"os"
 	"strings"
 	"wwfc/common"
 	"wwfc/database"
 	"wwfc/gpcm"
 	"wwfc/logging"
 
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/sasha-s/go-deadlock"
 )
@@ -37,8 +33,8 @@ type GameStatsSession struct {
 }
 
 var (
-	ctx  = context.Background()
-	pool *pgxpool.Pool
+	ctx   = context.Background()
+	users database.UserRepository
 
 	serverName string
 	webSalt    string
@@ -47,6 +43,10 @@ var (
 	mutex               = deadlock.RWMutex{}
 )
 
+func init() {
+	common.RegisterStateSection("gstats_sessions", 1, func() interface{} { return &map[uint64]*GameStatsSession{} }, nil)
+}
+
 func StartServer(reload bool) {
 	// Get config
 	config := common.GetConfig()
@@ -56,30 +56,9 @@ func StartServer(reload bool) {
 
 	common.ReadGameList()
 
-	// Start SQL
-	dbString := fmt.Sprintf("postgres://%s:%s@%s/%s", config.Username, config.Password, config.DatabaseAddress, config.DatabaseName)
-	dbConf, err := pgxpool.ParseConfig(dbString)
-	if err != nil {
-		panic(err)
-	}
-
-	pool, err = pgxpool.ConnectConfig(ctx, dbConf)
-	if err != nil {
-		panic(err)
-	}
-
 	if reload {
 		// Load state
-		file, err := os.Open("state/gstats_sessions.gob")
-		if err != nil {
-			panic(err)
-		}
-
-		decoder := gob.NewDecoder(file)
-
-		err = decoder.Decode(&sessionsByConnIndex)
-		file.Close()
-
+		err := common.LoadStateSection("gstats_sessions", &sessionsByConnIndex)
 		if err != nil {
 			panic(err)
 		}
@@ -98,24 +77,18 @@ func StartServer(reload bool) {
 	}
 }
 
+// SetUserRepository sets the repository used to look up profiles on login
+func SetUserRepository(repository database.UserRepository) {
+	users = repository
+}
+
 func Shutdown() {
 	// Save state
-	file, err := os.OpenFile("state/gstats_sessions.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		panic(err)
-	}
-
-	encoder := gob.NewEncoder(file)
-
-	err = encoder.Encode(sessionsByConnIndex)
-	file.Close()
-
+	err := common.SaveStateSection("gstats_sessions", sessionsByConnIndex)
 	if err != nil {
 		panic(err)
 	}
 
-	pool.Close()
-
 	logging.Notice("GSTATS", "Saved", aurora.Cyan(len(sessionsByConnIndex)), "sessions")
 }
 
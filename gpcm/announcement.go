@@ -0,0 +1,96 @@
+package gpcm
+
+import (
+	"sync"
+	"time"
+	"unicode/utf16"
+	"wwfc/common"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// How often scheduled announcements are checked
+const announcementInterval = 10 * time.Second
+
+var announcements database.AnnouncementRepository
+
+// SetAnnouncementRepository sets the repository holding scheduled announcements
+func SetAnnouncementRepository(repository database.AnnouncementRepository) {
+	announcements = repository
+}
+
+func announcementLoop() {
+	for {
+		time.Sleep(announcementInterval)
+		SendDueAnnouncements()
+	}
+}
+
+// Held while due announcements are claimed and sent, so a caller knows any announcement claimed by
+// another caller has been sent and its recipients recorded once SendDueAnnouncements returns
+var announcementMutex sync.Mutex
+
+// SendDueAnnouncements sends every announcement whose time has come and returns the number of players
+// reached by each, keyed by announcement ID
+func SendDueAnnouncements() map[int64]int {
+	announcementMutex.Lock()
+	defer announcementMutex.Unlock()
+
+	counts := map[int64]int{}
+
+	due, err := announcements.ClaimDueAnnouncements(ctx)
+	if err != nil {
+		logging.Error("GPCM", "Failed to fetch announcements:", err)
+		return counts
+	}
+
+	for _, announcement := range due {
+		count := sendAnnouncement(announcement)
+		counts[announcement.AnnouncementId] = count
+
+		logging.Notice("GPCM", "Sent announcement", aurora.Cyan(announcement.AnnouncementId), "to", aurora.Cyan(count), "players")
+		if err := announcements.SetAnnouncementRecipients(ctx, announcement.AnnouncementId, count); err != nil {
+			logging.Error("GPCM", "Failed to record announcement recipients:", err)
+		}
+	}
+
+	return counts
+}
+
+// sendAnnouncement pushes the message to every matching session whose payload reported it can display
+// wwfc_announce. Other clients would drop the command, so they're skipped and not counted.
+func sendAnnouncement(announcement database.Announcement) int {
+	messageUTF16 := utf16.Encode([]rune(announcement.Message))
+	message := common.CreateGameSpyMessage(common.GameSpyCommand{
+		Command:      "wwfc_announce",
+		CommandValue: "",
+		OtherValues: map[string]string{
+			"msg": common.Base64DwcEncoding.EncodeToString(common.UTF16ToByteArray(messageUTF16)),
+		},
+	})
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	count := 0
+	for _, session := range sessions {
+		if !session.LoggedIn || !session.CanShowAnnouncements {
+			continue
+		}
+
+		if announcement.ProfileId != 0 && session.User.ProfileId != announcement.ProfileId {
+			continue
+		}
+
+		if announcement.GameName != "" && session.GameName != announcement.GameName {
+			continue
+		}
+
+		common.SendPacket(ServerName, session.ConnIndex, []byte(message))
+		count++
+	}
+
+	return count
+}
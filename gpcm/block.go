@@ -0,0 +1,147 @@
+package gpcm
+
+import (
+	"strconv"
+	"strings"
+	"wwfc/common"
+	"wwfc/logging"
+	"wwfc/qr2"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+func (g *GameSpySession) isBlocking(profileId uint32) bool {
+	for _, blocked := range g.BlockList {
+		if blocked == profileId {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlockedWith checks if either session has blocked the other
+func (g *GameSpySession) isBlockedWith(session *GameSpySession) bool {
+	return g.isBlocking(session.User.ProfileId) || session.isBlocking(g.User.ProfileId)
+}
+
+// sendBlockList sends the stored block list to the client after login
+func (g *GameSpySession) sendBlockList() {
+	if len(g.BlockList) == 0 {
+		return
+	}
+
+	list := make([]string, len(g.BlockList))
+	for i, blocked := range g.BlockList {
+		list[i] = strconv.FormatUint(uint64(blocked), 10)
+	}
+
+	g.WriteBuffer += common.CreateGameSpyMessage(common.GameSpyCommand{
+		Command:      "blk",
+		CommandValue: strconv.Itoa(len(list)),
+		OtherValues: map[string]string{
+			"list": strings.Join(list, ","),
+		},
+	})
+}
+
+func (g *GameSpySession) addBlock(command common.GameSpyCommand) {
+	strProfileId := command.OtherValues["profileid"]
+	profileId64, err := strconv.ParseUint(strProfileId, 10, 32)
+	if err != nil || uint32(profileId64) == g.User.ProfileId {
+		logging.Error(g.ModuleName, "Invalid profile ID to block:", aurora.Cyan(strProfileId))
+		g.replyError(ErrAddBlock)
+		return
+	}
+	profileId := uint32(profileId64)
+
+	// Take the slot before writing to the database, so concurrent blocks can't go over the limit
+	mutex.Lock()
+	if g.isBlocking(profileId) {
+		mutex.Unlock()
+		g.replyError(ErrAddBlockAlreadyBlocked)
+		return
+	}
+
+	if len(g.BlockList) >= getFriendLimit(g.GameName) {
+		mutex.Unlock()
+		logging.Warn(g.ModuleName, "Block list is full")
+		g.replyError(ErrAddBlock)
+		return
+	}
+
+	g.BlockList = append(g.BlockList, profileId)
+	mutex.Unlock()
+
+	added, err := friends.AddBlock(ctx, g.User.ProfileId, profileId)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err != nil || !added {
+		for i, blocked := range g.BlockList {
+			if blocked == profileId {
+				removeFromUint32Array(&g.BlockList, i)
+				break
+			}
+		}
+
+		if err != nil {
+			logging.Error(g.ModuleName, "Failed to add block:", err)
+			g.replyError(ErrAddBlock)
+		} else {
+			g.replyError(ErrAddBlockAlreadyBlocked)
+		}
+		return
+	}
+
+	logging.Notice(g.ModuleName, "Blocked", aurora.Cyan(profileId))
+	qr2.SetBlockList(g.User.ProfileId, g.BlockList)
+
+	// Appear offline to the blocked player
+	if session, ok := sessions[profileId]; ok && session.LoggedIn && session.isFriendAuthorized(g.User.ProfileId) {
+		sendMessageToSession("100", g.User.ProfileId, session, logOutMessage)
+	}
+}
+
+func (g *GameSpySession) removeBlock(command common.GameSpyCommand) {
+	strProfileId := command.OtherValues["profileid"]
+	profileId64, err := strconv.ParseUint(strProfileId, 10, 32)
+	if err != nil {
+		logging.Error(g.ModuleName, "Invalid profile ID to unblock:", aurora.Cyan(strProfileId))
+		g.replyError(ErrRemoveBlock)
+		return
+	}
+	profileId := uint32(profileId64)
+
+	removed, err := friends.RemoveBlock(ctx, g.User.ProfileId, profileId)
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to remove block:", err)
+		g.replyError(ErrRemoveBlock)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	index := -1
+	for i, blocked := range g.BlockList {
+		if blocked == profileId {
+			index = i
+			break
+		}
+	}
+
+	if !removed && index == -1 {
+		g.replyError(ErrRemoveBlockNotBlocked)
+		return
+	}
+
+	logging.Notice(g.ModuleName, "Unblocked", aurora.Cyan(profileId))
+	if index != -1 {
+		removeFromUint32Array(&g.BlockList, index)
+	}
+	qr2.SetBlockList(g.User.ProfileId, g.BlockList)
+
+	// Restore the status updates hidden by the block
+	g.exchangeFriendStatus(profileId)
+}
@@ -0,0 +1,85 @@
+package gpcm
+
+import (
+	"wwfc/common"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// How a patched Wii login without a valid wwfc_sig is handled
+const (
+	// Every login must carry a valid signature
+	DeviceAuthRequire = "require"
+	// Unsigned logins are accepted but can't matchmake until wwfc_exlogin succeeds; invalid signatures are rejected
+	DeviceAuthRestrict = "restrict"
+	// Signatures are not checked at login, only by wwfc_exlogin
+	DeviceAuthAllow = "allow"
+)
+
+var (
+	deviceAuthPolicy       = DeviceAuthAllow
+	deviceAuthGamePolicies = map[string]string{}
+)
+
+func setDeviceAuthPolicies(config common.Config) {
+	deviceAuthPolicy = parseDeviceAuthPolicy(config.DeviceAuthPolicy)
+	deviceAuthGamePolicies = map[string]string{}
+	for _, game := range config.DeviceAuthGamePolicies {
+		deviceAuthGamePolicies[game.Name] = parseDeviceAuthPolicy(game.Policy)
+	}
+}
+
+// parseDeviceAuthPolicy fails closed on typos
+func parseDeviceAuthPolicy(policy string) string {
+	switch policy {
+	case DeviceAuthRequire, DeviceAuthRestrict, DeviceAuthAllow:
+		return policy
+	}
+
+	logging.Error("GPCM", "Invalid device authentication policy", aurora.Cyan(policy), "- using", aurora.Cyan(DeviceAuthRequire))
+	return DeviceAuthRequire
+}
+
+func getDeviceAuthPolicy(gameName string) string {
+	if policy, ok := deviceAuthGamePolicies[gameName]; ok {
+		return policy
+	}
+
+	return deviceAuthPolicy
+}
+
+// checkDeviceAuth applies the game's policy to a patched Wii login, returning the verified device ID (0 if none) and
+// whether the session counts as device authenticated. The error has already been sent if ok is false.
+func (g *GameSpySession) checkDeviceAuth(command common.GameSpyCommand, authToken string) (deviceId uint32, deviceAuth bool, ok bool) {
+	policy := getDeviceAuthPolicy(g.GameName)
+	_, signatureExists := command.OtherValues["wwfc_sig"]
+
+	result := ""
+	defer func() {
+		deviceAuthCount.Inc(policy, result)
+		logging.Info(g.ModuleName, "Device authentication:", aurora.Cyan(result), "with policy", aurora.Cyan(policy), g.logFields())
+	}()
+
+	if policy == DeviceAuthAllow {
+		result = "not_checked"
+		return 0, true, true
+	}
+
+	if !signatureExists && policy == DeviceAuthRestrict {
+		result = "unsigned_restricted"
+		return 0, false, true
+	}
+
+	deviceId, err := g.checkExLoginInfo(command, authToken)
+	if deviceId != 0 {
+		result = "signed"
+		return deviceId, true, true
+	}
+
+	// The login can't continue, so make sure the player sees why
+	result = "rejected"
+	err.Fatal = true
+	g.replyError(err)
+	return 0, false, false
+}
@@ -3,6 +3,8 @@ package gpcm
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 	"unicode/utf16"
 	"wwfc/common"
 	"wwfc/logging"
@@ -25,9 +27,13 @@ const (
 	LangSpanishEU = 0x84
 )
 
+// Longer reasons are cut off so the message still fits on screen
+const maxReasonLength = 64
+
 type WWFCErrorMessage struct {
-	ErrorCode  int
-	MessageRMC map[byte]string
+	ErrorCode int
+	// Key of the message template in the error message catalog
+	Name string
 }
 
 type GPError struct {
@@ -35,6 +41,9 @@ type GPError struct {
 	ErrorString string
 	Fatal       bool
 	WWFCMessage WWFCErrorMessage
+	// Optional moderator-written reason and ban expiry, added to the WWFC message
+	Reason  string
+	Expires *time.Time
 }
 
 func MakeGPError(errorCode int, errorString string, fatal bool) GPError {
@@ -94,13 +103,15 @@ var (
 	ErrUpdateProfile            = MakeGPError(0x0500, "There was an error updating the profile information.", false)
 	ErrUpdateProfileBadNickname = MakeGPError(0x0501, "A user with the nickname provided already exists.", false)
 
-	// Add friend errors
+	// Add friend errors. The GP protocol has no code for a full buddy list, so ErrAddFriendListFull and
+	// ErrAuthAddListFull reuse the generic codes. Games treat them like any other failed add.
 	ErrAddFriend               = MakeGPError(0x0600, "There was an error adding a buddy.", false)
 	ErrAddFriendBadFrom        = MakeGPError(0x0601, "The profile requesting to add a buddy is invalid.", false)
 	ErrAddFriendBadNew         = MakeGPError(0x0602, "The profile requested is invalid.", false)
 	ErrAddFriendAlreadyFriends = MakeGPError(0x0603, "The profile requested is already a buddy.", false)
 	ErrAddFriendLocalBlock     = MakeGPError(0x0604, "The profile requested is on the local profile's block list.", false)
 	ErrAddFriendBlocked        = MakeGPError(0x0605, "The profile requested is blocking you.", false)
+	ErrAddFriendListFull       = MakeGPError(0x0600, "The buddy list is full.", false)
 
 	// Auth add friend errors
 	ErrAuthAdd             = MakeGPError(0x0700, "There was an error authorizing an add buddy request.", false)
@@ -108,6 +119,7 @@ var (
 	ErrAuthAddBadSignature = MakeGPError(0x0702, "The signature for the authorization is invalid.", false)
 	ErrAuthAddLocalBlock   = MakeGPError(0x0703, "The profile requesting authorization is on a block list.", false)
 	ErrAuthAddBlocked      = MakeGPError(0x0704, "The profile requested is blocking you.", false)
+	ErrAuthAddListFull     = MakeGPError(0x0700, "The buddy list is full.", false)
 
 	// Status errors
 	ErrStatus = MakeGPError(0x0800, "There was an error with the status string.", false)
@@ -167,190 +179,22 @@ var (
 )
 
 var (
-	// WWFC errors with custom messages
-	WWFCMsgUnknownLoginError = WWFCErrorMessage{
-		ErrorCode: 22000,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"An unknown error has occurred\n" +
-				"while logging in to NewWFC.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgDolphinSetupRequired = WWFCErrorMessage{
-		ErrorCode: 22001,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"Additional setup is required\n" +
-				"to use NewWFC on Dolphin.\n" +
-				"Visit newwfc.xyz/dolphin\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgProfileBannedTOS = WWFCErrorMessage{
-		ErrorCode: 22002,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You are banned from WiiLink WFC\n" +
-				"due to a violation of the\n" +
-				"Terms of Service.\n" +
-				"Visit newwfc.xyz/tos\n" +
-				"\n" +
-				"Error Code: %[1]d\n" +
-				"Support Info: NG%08[2]x",
-		},
-	}
-
-	WWFCMsgProfileBannedTOSNow = WWFCErrorMessage{
-		ErrorCode: 22002,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You have been banned from\n" +
-				"NewWFC due to a violation\n" +
-				"of the Terms of Service.\n" +
-				"Visit NewWFC.xyz/tos\n" +
-				"\n" +
-				"Error Code: %[1]d\n" +
-				"Support Info: NG%08[2]x",
-		},
-	}
-
-	WWFCMsgProfileRestricted = WWFCErrorMessage{
-		ErrorCode: 22003,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You are banned from public\n" +
-				"matches due to a violation\n" +
-				"of the NewWFC Rules.\n" +
-				"Visit newwfc.xyz/rules\n" +
-				"\n" +
-				"Error Code: %[1]d\n" +
-				"Support Info: NG%08[2]x",
-		},
-	}
-
-	WWFCMsgProfileRestrictedNow = WWFCErrorMessage{
-		ErrorCode: 22003,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You have been banned from public\n" +
-				"matches due to a violation\n" +
-				"of the NewWFC Rules.\n" +
-				"Visit newwfc.xyz/rules\n" +
-				"\n" +
-				"Error Code: %[1]d\n" +
-				"Support Info: NG%08[2]x",
-		},
-	}
-
-	WWFCMsgKickedGeneric = WWFCErrorMessage{
-		ErrorCode: 22004,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You have been kicked from\n" +
-				"NewWFC.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgKickedModerator = WWFCErrorMessage{
-		ErrorCode: 22004,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You have been kicked from\n" +
-				"NewWFC by a moderator.\n" +
-				"Visit newwfc.xyz/rules\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgKickedRoomHost = WWFCErrorMessage{
-		ErrorCode: 22004,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You have been kicked from the\n" +
-				"friend room by the room creator.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgConsoleMismatch = WWFCErrorMessage{
-		ErrorCode: 22005,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"The console you are using is not\n" +
-				"the device used to register this\n" +
-				"profile.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgConsoleMismatchDolphin = WWFCErrorMessage{
-		ErrorCode: 22005,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"The console you are using is not\n" +
-				"the device used to register this\n" +
-				"profile. Please make sure you've\n" +
-				"set up your NAND correctly.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgProfileIDInvalid = WWFCErrorMessage{
-		ErrorCode: 22006,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"The profile ID you are trying to\n" +
-				"register is invalid.\n" +
-				"Please create a new license.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgProfileIDInUse = WWFCErrorMessage{
-		ErrorCode: 22007,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"The friend code you are trying to\n" +
-				"register is already in use.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgPayloadInvalid = WWFCErrorMessage{
-		ErrorCode: 22008,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"The NewWFC payload is invalid.\n" +
-				"Try restarting your game.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
-
-	WWFCMsgInvalidELO = WWFCErrorMessage{
-		ErrorCode: 22009,
-		MessageRMC: map[byte]string{
-			LangEnglish: "" +
-				"You were disconnected from\n" +
-				"NewWFC due to an invalid\n" +
-				"VR or BR value.\n" +
-				"\n" +
-				"Error Code: %[1]d",
-		},
-	}
+	// WWFC errors with custom messages, the text of which comes from the error message catalog
+	WWFCMsgUnknownLoginError      = WWFCErrorMessage{ErrorCode: 22000, Name: "unknown_login_error"}
+	WWFCMsgDolphinSetupRequired   = WWFCErrorMessage{ErrorCode: 22001, Name: "dolphin_setup_required"}
+	WWFCMsgProfileBannedTOS       = WWFCErrorMessage{ErrorCode: 22002, Name: "banned_tos"}
+	WWFCMsgProfileBannedTOSNow    = WWFCErrorMessage{ErrorCode: 22002, Name: "banned_tos_now"}
+	WWFCMsgProfileRestricted      = WWFCErrorMessage{ErrorCode: 22003, Name: "restricted"}
+	WWFCMsgProfileRestrictedNow   = WWFCErrorMessage{ErrorCode: 22003, Name: "restricted_now"}
+	WWFCMsgKickedGeneric          = WWFCErrorMessage{ErrorCode: 22004, Name: "kicked"}
+	WWFCMsgKickedModerator        = WWFCErrorMessage{ErrorCode: 22004, Name: "kicked_moderator"}
+	WWFCMsgKickedRoomHost         = WWFCErrorMessage{ErrorCode: 22004, Name: "kicked_room_host"}
+	WWFCMsgConsoleMismatch        = WWFCErrorMessage{ErrorCode: 22005, Name: "console_mismatch"}
+	WWFCMsgConsoleMismatchDolphin = WWFCErrorMessage{ErrorCode: 22005, Name: "console_mismatch_dolphin"}
+	WWFCMsgProfileIDInvalid       = WWFCErrorMessage{ErrorCode: 22006, Name: "profile_id_invalid"}
+	WWFCMsgProfileIDInUse         = WWFCErrorMessage{ErrorCode: 22007, Name: "profile_id_in_use"}
+	WWFCMsgPayloadInvalid         = WWFCErrorMessage{ErrorCode: 22008, Name: "payload_invalid"}
+	WWFCMsgInvalidELO             = WWFCErrorMessage{ErrorCode: 22009, Name: "invalid_elo"}
 )
 
 func (err GPError) GetMessage() string {
@@ -370,7 +214,7 @@ func (err GPError) GetMessage() string {
 	return common.CreateGameSpyMessage(command)
 }
 
-func (err GPError) GetMessageTranslate(gameName string, region byte, lang byte, cfc uint64, ngid uint32) string {
+func (err GPError) GetMessageTranslate(gameName string, region byte, lang byte, patched bool, cfc uint64, ngid uint32) string {
 	command := common.GameSpyCommand{
 		Command:      "error",
 		CommandValue: "",
@@ -384,15 +228,10 @@ func (err GPError) GetMessageTranslate(gameName string, region byte, lang byte,
 		command.OtherValues["fatal"] = ""
 	}
 
-	if err.Fatal && err.WWFCMessage.ErrorCode != 0 {
-		switch gameName {
-		case "mariokartwii":
-			errMsg := err.WWFCMessage.MessageRMC[lang]
-			if errMsg == "" {
-				errMsg = err.WWFCMessage.MessageRMC[LangEnglish]
-			}
-
-			errMsg = fmt.Sprintf(errMsg, err.WWFCMessage.ErrorCode, ngid)
+	// Games other than Mario Kart Wii can only display wwfc_errmsg with the WiiLink WFC payload
+	if err.Fatal && err.WWFCMessage.ErrorCode != 0 && (gameName == "mariokartwii" || patched) {
+		if errMsg, ok := getErrorMessage(err.WWFCMessage.Name, gameName, lang); ok {
+			errMsg = fmt.Sprintf(errMsg, err.WWFCMessage.ErrorCode, ngid, err.getDetails(gameName, lang))
 			errMsgUTF16 := utf16.Encode([]rune(errMsg))
 			errMsgByteArray := common.UTF16ToByteArray(errMsgUTF16)
 
@@ -404,8 +243,35 @@ func (err GPError) GetMessageTranslate(gameName string, region byte, lang byte,
 	return common.CreateGameSpyMessage(command)
 }
 
+// getDetails formats the reason and expiry lines that templates place with %[3]s
+func (err GPError) getDetails(gameName string, lang byte) string {
+	details := ""
+
+	if reason := []rune(strings.TrimSpace(err.Reason)); len(reason) != 0 {
+		if len(reason) > maxReasonLength {
+			reason = append(reason[:maxReasonLength-3], []rune("...")...)
+		}
+
+		if template, ok := getErrorMessage("reason", gameName, lang); ok {
+			details += fmt.Sprintf(template, string(reason)) + "\n"
+		}
+	}
+
+	if err.Expires != nil {
+		if template, ok := getErrorMessage("expires", gameName, lang); ok {
+			details += fmt.Sprintf(template, err.Expires.UTC().Format("2006-01-02 15:04")+" UTC") + "\n"
+		}
+	}
+
+	return details
+}
+
 func (g *GameSpySession) replyError(err GPError) {
-	logging.Error(g.ModuleName, "Reply error:", err.ErrorString)
+	logging.Error(g.ModuleName, "Reply error:", err.ErrorString, g.logFields())
+	if !g.LoggedIn {
+		loginCount.Inc("error", strconv.Itoa(err.ErrorCode))
+	}
+
 	if !g.LoginInfoSet {
 		msg := err.GetMessage()
 		// logging.Info(g.ModuleName, "Sending error message:", msg)
@@ -421,7 +287,7 @@ func (g *GameSpySession) replyError(err GPError) {
 		deviceId = g.User.NgDeviceId
 	}
 
-	msg := err.GetMessageTranslate(g.GameName, g.Region, g.Language, g.ConsoleFriendCode, deviceId)
+	msg := err.GetMessageTranslate(g.GameName, g.Region, g.Language, g.Patched, g.ConsoleFriendCode, deviceId)
 	// logging.Info(g.ModuleName, "Sending error message:", msg)
 	common.SendPacket(ServerName, g.ConnIndex, []byte(msg))
 	if err.Fatal {
@@ -0,0 +1,124 @@
+package gpcm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// Directory holding one error message catalog file per language, e.g. locales/en.json
+var errorCatalogPath = "./locales"
+
+// Catalog file name for each language the games report
+var catalogLanguages = map[string]byte{
+	"ja":      LangJapanese,
+	"en":      LangEnglish,
+	"de":      LangGerman,
+	"fr":      LangFrench,
+	"es":      LangSpanish,
+	"it":      LangItalian,
+	"nl":      LangDutch,
+	"zh-Hans": LangSimpChinese,
+	"zh-Hant": LangTradChinese,
+	"ko":      LangKorean,
+	"en-EU":   LangEnglishEU,
+	"fr-EU":   LangFrenchEU,
+	"es-EU":   LangSpanishEU,
+}
+
+// Language tried next when a message is missing, before finally falling back to English
+var languageFallback = map[byte]byte{
+	LangEnglishEU: LangEnglish,
+	LangFrenchEU:  LangFrench,
+	LangSpanishEU: LangSpanish,
+}
+
+type errorCatalog struct {
+	// Message templates by name, formatted with the WWFC error code and the NG device ID
+	Messages map[string]string `json:"messages"`
+	// Per-game overrides of the above
+	Games map[string]map[string]string `json:"games"`
+}
+
+var (
+	errorCatalogs      = map[byte]errorCatalog{}
+	errorCatalogsMutex sync.RWMutex
+)
+
+// ReloadErrorMessages reads every catalog file again. The current catalogs are kept if any file fails to parse.
+func ReloadErrorMessages() (int, error) {
+	catalogs := map[byte]errorCatalog{}
+
+	for name, lang := range catalogLanguages {
+		contents, err := os.ReadFile(filepath.Join(errorCatalogPath, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+
+		var catalog errorCatalog
+		if err := json.Unmarshal(contents, &catalog); err != nil {
+			return 0, fmt.Errorf("%s.json: %w", name, err)
+		}
+
+		catalogs[lang] = catalog
+	}
+
+	errorCatalogsMutex.Lock()
+	errorCatalogs = catalogs
+	errorCatalogsMutex.Unlock()
+
+	return len(catalogs), nil
+}
+
+// getErrorMessage finds the template for a message, trying the game's override before the language's default
+// at each step of the fallback chain
+func getErrorMessage(name string, gameName string, lang byte) (string, bool) {
+	errorCatalogsMutex.RLock()
+	defer errorCatalogsMutex.RUnlock()
+
+	chain := []byte{lang}
+	if fallback, ok := languageFallback[lang]; ok {
+		chain = append(chain, fallback)
+	}
+	if chain[len(chain)-1] != LangEnglish {
+		chain = append(chain, LangEnglish)
+	}
+
+	for _, lang := range chain {
+		catalog, ok := errorCatalogs[lang]
+		if !ok {
+			continue
+		}
+
+		if message, ok := catalog.Games[gameName][name]; ok {
+			return message, true
+		}
+		if message, ok := catalog.Messages[name]; ok {
+			return message, true
+		}
+	}
+
+	return "", false
+}
+
+func loadErrorMessages() {
+	count, err := ReloadErrorMessages()
+	if err != nil {
+		logging.Error("GPCM", "Failed to load error messages:", err)
+		return
+	}
+
+	if count == 0 {
+		logging.Warn("GPCM", "No error message catalogs found in", aurora.Cyan(errorCatalogPath))
+		return
+	}
+
+	logging.Notice("GPCM", "Loaded error messages for", aurora.Cyan(count), "languages")
+}
@@ -0,0 +1,96 @@
+package gpcm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestErrorMessageFallback(t *testing.T) {
+	dir := t.TempDir()
+	errorCatalogPath = dir
+	defer func() {
+		errorCatalogPath = "./locales"
+		errorCatalogs = map[byte]errorCatalog{}
+	}()
+
+	files := map[string]string{
+		"en.json": `{"messages": {"kicked": "en kicked", "invalid_elo": "en elo"}, "games": {"mariokartwii": {"invalid_elo": "en mkw elo"}}}`,
+		"fr.json": `{"messages": {"kicked": "fr kicked"}}`,
+	}
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count, err := ReloadErrorMessages(); err != nil || count != 2 {
+		t.Fatalf("ReloadErrorMessages() = %d, %v", count, err)
+	}
+
+	tests := []struct {
+		name     string
+		gameName string
+		lang     byte
+		want     string
+	}{
+		{"kicked", "mariokartwii", LangFrenchEU, "fr kicked"},
+		{"kicked", "mariokartwii", LangEnglishEU, "en kicked"},
+		{"invalid_elo", "mariokartwii", LangFrench, "en mkw elo"},
+		{"invalid_elo", "animalcrossingwii", LangKorean, "en elo"},
+	}
+
+	for _, test := range tests {
+		got, ok := getErrorMessage(test.name, test.gameName, test.lang)
+		if !ok || got != test.want {
+			t.Errorf("getErrorMessage(%q, %q, %#x) = %q, %v; want %q", test.name, test.gameName, test.lang, got, ok, test.want)
+		}
+	}
+
+	if _, ok := getErrorMessage("missing", "mariokartwii", LangEnglish); ok {
+		t.Error("found a message that is in no catalog")
+	}
+
+	// A broken file must not replace the loaded catalogs
+	os.WriteFile(filepath.Join(dir, "de.json"), []byte("{"), 0600)
+	if _, err := ReloadErrorMessages(); err == nil {
+		t.Error("ReloadErrorMessages() accepted invalid JSON")
+	}
+	if got, _ := getErrorMessage("kicked", "", LangFrench); got != "fr kicked" {
+		t.Errorf("catalog was replaced after a failed reload, got %q", got)
+	}
+}
+
+func TestErrorMessageCatalogs(t *testing.T) {
+	errorCatalogPath = "../locales"
+	defer func() {
+		errorCatalogPath = "./locales"
+		errorCatalogs = map[byte]errorCatalog{}
+	}()
+
+	if _, err := ReloadErrorMessages(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every WWFC message needs an English template so the fallback chain always ends somewhere
+	for _, message := range []WWFCErrorMessage{
+		WWFCMsgUnknownLoginError, WWFCMsgDolphinSetupRequired, WWFCMsgProfileBannedTOS, WWFCMsgProfileBannedTOSNow,
+		WWFCMsgProfileRestricted, WWFCMsgProfileRestrictedNow, WWFCMsgKickedGeneric, WWFCMsgKickedModerator,
+		WWFCMsgKickedRoomHost, WWFCMsgConsoleMismatch, WWFCMsgConsoleMismatchDolphin, WWFCMsgProfileIDInvalid,
+		WWFCMsgProfileIDInUse, WWFCMsgPayloadInvalid, WWFCMsgInvalidELO,
+	} {
+		if _, ok := errorCatalogs[LangEnglish].Messages[message.Name]; !ok {
+			t.Errorf("locales/en.json is missing %q", message.Name)
+		}
+	}
+
+	expires := time.Date(2024, 5, 1, 18, 30, 0, 0, time.UTC)
+	err := GPError{Reason: "Cheating", Expires: &expires}
+	if got, want := err.getDetails("mariokartwii", LangEnglishEU), "Reason: Cheating\nExpires: 2024-05-01 18:30 UTC\n"; got != want {
+		t.Errorf("getDetails() = %q; want %q", got, want)
+	}
+	if got := (GPError{}).getDetails("mariokartwii", LangEnglish); got != "" {
+		t.Errorf("getDetails() without a reason or expiry = %q", got)
+	}
+}
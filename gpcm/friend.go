@@ -26,6 +26,44 @@ func removeFromUint32Array(arrayPointer *[]uint32, index int) error {
 	return nil
 }
 
+// Used for games without a roster size in the game list
+const defaultFriendLimit = 100
+
+// getFriendLimit returns the maximum roster size for the game
+func getFriendLimit(gameName string) int {
+	if gameInfo := common.GetGameInfoByName(gameName); gameInfo != nil && gameInfo.MaxFriends > 0 {
+		return gameInfo.MaxFriends
+	}
+
+	return defaultFriendLimit
+}
+
+func (g *GameSpySession) isFriendListFull() bool {
+	return len(g.FriendList) >= getFriendLimit(g.GameName)
+}
+
+func (g *GameSpySession) isAuthFriendListFull() bool {
+	return len(g.AuthFriendList) >= getFriendLimit(g.GameName)
+}
+
+// trimFriendLists drops the newest entries beyond the game's roster size
+func (g *GameSpySession) trimFriendLists() bool {
+	limit := getFriendLimit(g.GameName)
+	trimmed := false
+
+	if len(g.FriendList) > limit {
+		g.FriendList = g.FriendList[:limit]
+		trimmed = true
+	}
+
+	if len(g.AuthFriendList) > limit {
+		g.AuthFriendList = g.AuthFriendList[:limit]
+		trimmed = true
+	}
+
+	return trimmed
+}
+
 func (g *GameSpySession) isFriendAdded(profileId uint32) bool {
 	for _, storedPid := range g.FriendList {
 		if storedPid == profileId {
@@ -93,7 +131,50 @@ func (g *GameSpySession) addFriend(command common.GameSpyCommand) {
 	logging.Info(g.ModuleName, "Add friend:", aurora.Cyan(strNewProfileId), aurora.Cyan(fc))
 
 	mutex.Lock()
-	defer mutex.Unlock()
+	blocking := g.isBlocking(uint32(newProfileId))
+	added := g.isFriendAdded(uint32(newProfileId))
+	listFull := !added && g.isFriendListFull()
+	if !blocking && !listFull && !added {
+		// Take the slot now, so concurrent adds can't go over the limit while the database is written
+		g.FriendList = append(g.FriendList, uint32(newProfileId))
+	}
+	mutex.Unlock()
+
+	if blocking {
+		logging.Info(g.ModuleName, "Attempt to add a blocked player")
+		g.replyError(ErrAddFriendLocalBlock)
+		return
+	}
+
+	if listFull {
+		logging.Warn(g.ModuleName, "Friend list is full")
+		g.replyError(ErrAddFriendListFull)
+		return
+	}
+
+	if err := friends.AddFriend(ctx, g.User.ProfileId, uint32(newProfileId)); err != nil {
+		logging.Error(g.ModuleName, "Failed to save friend:", err)
+	}
+
+	// Don't hold the session mutex while writing to the database
+	authorizedNow := false
+	destinationOffline := false
+	mutex.Lock()
+	defer func() {
+		mutex.Unlock()
+
+		if authorizedNow {
+			if err := friends.AuthorizeFriendship(ctx, g.User.ProfileId, uint32(newProfileId)); err != nil {
+				logging.Error(g.ModuleName, "Failed to save friend authorization:", err)
+			}
+		}
+
+		if destinationOffline {
+			g.storeFriendRequest(uint32(newProfileId))
+			// The destination may have already sent a request to us while we were offline
+			g.deliverFriendRequests(uint32(newProfileId))
+		}
+	}()
 
 	authorized := g.isFriendAuthorized(uint32(newProfileId))
 	if !g.User.OpenHost && authorized {
@@ -104,15 +185,11 @@ func (g *GameSpySession) addFriend(command common.GameSpyCommand) {
 		return
 	}
 
-	// TODO: Add a limit
-	if !g.isFriendAdded(uint32(newProfileId)) {
-		g.FriendList = append(g.FriendList, uint32(newProfileId))
-	}
-
 	// Check if destination has added the sender
 	newSession, ok := sessions[uint32(newProfileId)]
 	if !ok || newSession == nil || !newSession.LoggedIn {
 		logging.Info(g.ModuleName, "Destination is not online")
+		destinationOffline = true
 		return
 	}
 
@@ -122,17 +199,29 @@ func (g *GameSpySession) addFriend(command common.GameSpyCommand) {
 		return
 	}
 
+	if newSession.isBlocking(g.User.ProfileId) {
+		logging.Info(g.ModuleName, "Destination has blocked sender")
+		g.replyError(ErrAddFriendBlocked)
+		return
+	}
+
 	if !newSession.User.OpenHost && !newSession.isFriendAdded(g.User.ProfileId) {
 		// Not an error, just ignore for now
 		logging.Info(g.ModuleName, "Destination has not added sender")
 		return
 	}
 
+	if !authorized && (g.isAuthFriendListFull() || newSession.isAuthFriendListFull()) {
+		logging.Warn(g.ModuleName, "Cannot authorize", aurora.Cyan(newProfileId), "because a friend list is full")
+		g.replyError(ErrAddFriendListFull)
+		return
+	}
+
 	// Friends are now mutual!
-	// TODO: Add a limit
 	if !authorized {
 		g.AuthFriendList = append(g.AuthFriendList, uint32(newProfileId))
 		newSession.AuthFriendList = append(newSession.AuthFriendList, g.User.ProfileId)
+		authorizedNow = true
 	}
 
 	// Send friend auth message
@@ -169,6 +258,14 @@ func (g *GameSpySession) removeFriend(command common.GameSpyCommand) {
 	fc := common.CalcFriendCodeString(delProfileID32, g.User.GsbrCode[:4])
 	logging.Info(g.ModuleName, "Remove friend:", aurora.Cyan(strDelProfileID), aurora.Cyan(fc))
 
+	if err := friends.RemoveFriend(ctx, g.User.ProfileId, delProfileID32); err != nil {
+		logging.Error(g.ModuleName, "Failed to remove friend:", err)
+	}
+
+	if err := friends.DeleteFriendRequest(ctx, g.User.ProfileId, delProfileID32); err != nil {
+		logging.Error(g.ModuleName, "Failed to cancel friend request:", err)
+	}
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
@@ -198,13 +295,32 @@ func (g *GameSpySession) authAddFriend(command common.GameSpyCommand) {
 		return
 	}
 
+	// The authorization may have happened in an earlier session
+	storedAuthorized, err := friends.IsFriendAuthorized(ctx, g.User.ProfileId, uint32(fromProfileId))
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to check friend authorization:", err)
+	}
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
 	if !g.isFriendAuthorized(uint32(fromProfileId)) {
-		logging.Error(g.ModuleName, "Sender", aurora.Cyan(fromProfileId), "is not an authorized friend")
-		g.replyError(ErrAuthAddBadFrom)
-		return
+		if !storedAuthorized {
+			logging.Error(g.ModuleName, "Sender", aurora.Cyan(fromProfileId), "is not an authorized friend")
+			g.replyError(ErrAuthAddBadFrom)
+			return
+		}
+
+		if g.isAuthFriendListFull() {
+			logging.Warn(g.ModuleName, "Cannot authorize", aurora.Cyan(fromProfileId), "because the friend list is full")
+			g.replyError(ErrAuthAddListFull)
+			return
+		}
+
+		g.AuthFriendList = append(g.AuthFriendList, uint32(fromProfileId))
+		if session, ok := sessions[uint32(fromProfileId)]; ok && session.LoggedIn && !session.isFriendAuthorized(g.User.ProfileId) && !session.isAuthFriendListFull() {
+			session.AuthFriendList = append(session.AuthFriendList, g.User.ProfileId)
+		}
 	}
 
 	g.exchangeFriendStatus(uint32(fromProfileId))
@@ -292,7 +408,7 @@ func (g *GameSpySession) sendFriendStatus(profileId uint32) {
 		return
 	}
 
-	if session, ok := sessions[profileId]; ok && session.LoggedIn && session.isFriendAdded(g.User.ProfileId) {
+	if session, ok := sessions[profileId]; ok && session.LoggedIn && session.isFriendAdded(g.User.ProfileId) && !g.isBlockedWith(session) {
 		// Prevent players abusing a stack overflow exploit with the locstring in Mario Kart Wii
 		if session.NeedsExploit && strings.HasPrefix(session.GameCode, "RMC") && len(g.LocString) > 0x14 {
 			logging.Warn("GPCM", "Blocked message from", aurora.Cyan(g.User.ProfileId), "to", aurora.Cyan(session.User.ProfileId), "due to a stack overflow exploit")
@@ -305,7 +421,7 @@ func (g *GameSpySession) sendFriendStatus(profileId uint32) {
 }
 
 func (g *GameSpySession) exchangeFriendStatus(profileId uint32) {
-	if session, ok := sessions[profileId]; ok && session.LoggedIn {
+	if session, ok := sessions[profileId]; ok && session.LoggedIn && !g.isBlockedWith(session) {
 		if session.isFriendAdded(g.User.ProfileId) && session.isFriendAuthorized(g.User.ProfileId) {
 			if session.NeedsExploit && strings.HasPrefix(session.GameCode, "RMC") && len(g.LocString) > 0x14 {
 				logging.Warn("GPCM", "Blocked message from", aurora.Cyan(g.User.ProfileId), "to", aurora.Cyan(session.User.ProfileId), "due to a stack overflow exploit")
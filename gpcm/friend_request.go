@@ -0,0 +1,95 @@
+package gpcm
+
+import (
+	"time"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+var friendRequestExpiry = 30 * 24 * time.Hour
+
+// storeFriendRequest keeps an addbuddy to an offline player so it can be completed at their next login
+func (g *GameSpySession) storeFriendRequest(toProfileId uint32) {
+	err := friends.AddFriendRequest(ctx, g.User.ProfileId, toProfileId, friendRequestExpiry)
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to store friend request:", err)
+		return
+	}
+
+	logging.Info(g.ModuleName, "Stored friend request to", aurora.Cyan(toProfileId))
+}
+
+// deliverFriendRequests completes the pending requests sent to this player by anyone they have also added.
+// If fromProfileId is nonzero, only the request from that profile is considered.
+// Must be called without the session mutex held.
+func (g *GameSpySession) deliverFriendRequests(fromProfileId uint32) {
+	requests, err := friends.GetFriendRequests(ctx, g.User.ProfileId)
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to fetch friend requests:", err)
+		return
+	}
+
+	for _, request := range requests {
+		if request.ToProfileId != g.User.ProfileId || (fromProfileId != 0 && request.FromProfileId != fromProfileId) {
+			continue
+		}
+
+		// The request stays pending until this player adds the sender back
+		mutex.Lock()
+		added := g.isFriendAdded(request.FromProfileId) && !g.isBlocking(request.FromProfileId)
+		mutex.Unlock()
+		if !added {
+			continue
+		}
+
+		delivered, err := friends.MarkFriendRequestDelivered(ctx, request.FromProfileId, g.User.ProfileId)
+		if err != nil {
+			logging.Error(g.ModuleName, "Failed to mark friend request as delivered:", err)
+			continue
+		} else if !delivered {
+			continue
+		}
+
+		if err := friends.AuthorizeFriendship(ctx, g.User.ProfileId, request.FromProfileId); err != nil {
+			logging.Error(g.ModuleName, "Failed to save friend authorization:", err)
+		}
+
+		logging.Notice(g.ModuleName, "Delivering friend request from", aurora.Cyan(request.FromProfileId))
+		g.completeFriendRequest(request.FromProfileId)
+	}
+}
+
+func (g *GameSpySession) completeFriendRequest(fromProfileId uint32) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !g.isFriendAuthorized(fromProfileId) {
+		if g.isAuthFriendListFull() {
+			logging.Warn(g.ModuleName, "Cannot authorize", aurora.Cyan(fromProfileId), "because the friend list is full")
+			return
+		}
+		g.AuthFriendList = append(g.AuthFriendList, fromProfileId)
+	}
+
+	sendMessageToSessionBuffer("4", fromProfileId, g, "")
+	if g.isBm1AuthMessageNeeded() {
+		sendMessageToSessionBuffer("1", fromProfileId, g, bm1AuthMessage)
+	}
+
+	fromSession, ok := sessions[fromProfileId]
+	if !ok || !fromSession.LoggedIn {
+		// Let the sender know once they're back
+		go g.storeFriendRequest(fromProfileId)
+		return
+	}
+
+	if !fromSession.isFriendAuthorized(g.User.ProfileId) && !fromSession.isAuthFriendListFull() {
+		fromSession.AuthFriendList = append(fromSession.AuthFriendList, g.User.ProfileId)
+	}
+
+	sendMessageToSession("4", g.User.ProfileId, fromSession, "")
+	if fromSession.isBm1AuthMessageNeeded() {
+		sendMessageToSession("1", g.User.ProfileId, fromSession, bm1AuthMessage)
+	}
+}
@@ -1,8 +1,16 @@
 package gpcm
 
-import "wwfc/common"
+import (
+	"time"
+	"wwfc/common"
+)
 
 func kickPlayer(profileID uint32, reason string) {
+	kickPlayerWithReason(profileID, reason, "", nil)
+}
+
+// kickPlayerWithReason disconnects the player, showing them the optional reason text and ban expiry
+func kickPlayerWithReason(profileID uint32, reason string, message string, expires *time.Time) {
 	if session, exists := sessions[profileID]; exists {
 		errorMessage := WWFCMsgKickedGeneric
 
@@ -15,6 +23,11 @@ func kickPlayer(profileID uint32, reason string) {
 
 		case "restricted_join":
 			errorMessage = WWFCMsgProfileRestricted
+			// The restriction was found at login
+			if message == "" && expires == nil {
+				message = session.User.BanReason
+				expires = session.User.BanExpires
+			}
 
 		case "moderator_kick":
 			errorMessage = WWFCMsgKickedModerator
@@ -27,15 +40,19 @@ func kickPlayer(profileID uint32, reason string) {
 
 		case "network_error":
 			// No error message
+			session.setDisconnectReason("kick:" + reason)
 			common.CloseConnection(ServerName, session.ConnIndex)
 			return
 		}
 
+		session.setDisconnectReason("kick:" + reason)
 		session.replyError(GPError{
 			ErrorCode:   ErrConnectionClosed.ErrorCode,
 			ErrorString: "The player was kicked from the server. Reason: " + reason,
 			Fatal:       true,
 			WWFCMessage: errorMessage,
+			Reason:      message,
+			Expires:     expires,
 		})
 		common.CloseConnection(ServerName, session.ConnIndex)
 	}
@@ -47,3 +64,11 @@ func KickPlayer(profileID uint32, reason string) {
 
 	kickPlayer(profileID, reason)
 }
+
+// KickPlayerWithReason is KickPlayer with text written by a moderator and, for bans, the time the ban expires
+func KickPlayerWithReason(profileID uint32, reason string, message string, expires *time.Time) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	kickPlayerWithReason(profileID, reason, message, expires)
+}
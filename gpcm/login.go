@@ -7,12 +7,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"unicode/utf16"
 	"wwfc/common"
 	"wwfc/database"
+	"wwfc/events"
 	"wwfc/logging"
 	"wwfc/qr2"
 
@@ -70,6 +72,17 @@ var commonDeviceIds = []uint32{
 	0x247dd10b,
 }
 
+// IsCommonDeviceId reports whether the device ID belongs to a key shared by many consoles
+func IsCommonDeviceId(deviceId uint32) bool {
+	for _, commonDeviceId := range commonDeviceIds {
+		if deviceId == commonDeviceId {
+			return true
+		}
+	}
+
+	return false
+}
+
 func verifySignature(moduleName string, authToken string, signature string) uint32 {
 	sigBytes, err := common.Base64DwcEncoding.DecodeString(signature)
 	if err != nil || len(sigBytes) != 0x144 {
@@ -80,10 +93,8 @@ func verifySignature(moduleName string, authToken string, signature string) uint
 
 	if !allowDefaultDolphinKeys {
 		// Skip authentication signature verification for common device IDs (the caller should handle this)
-		for _, defaultDeviceId := range commonDeviceIds {
-			if binary.BigEndian.Uint32(ngId) == defaultDeviceId {
-				return defaultDeviceId
-			}
+		if deviceId := binary.BigEndian.Uint32(ngId); IsCommonDeviceId(deviceId) {
+			return deviceId
 		}
 	}
 
@@ -197,6 +208,9 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 	}
 
 	g.LoginInfoSet = true
+	g.Patched = payloadVerExists || signatureExists
+	// Payloads list the optional messages they can display in wwfc_caps, e.g. "announce"
+	g.CanShowAnnouncements = g.Patched && slices.Contains(strings.Split(command.OtherValues["wwfc_caps"], ","), "announce")
 
 	expectedUnitCode := common.GetExpectedUnitCode(g.GameName)
 	if (g.UnitCode != UnitCodeDS && g.UnitCode != UnitCodeWii) || (g.UnitCode != expectedUnitCode && expectedUnitCode != UnitCodeDSAndWii) {
@@ -221,12 +235,12 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 
 			g.NeedsExploit = true
 			deviceAuth = false
+			deviceAuthCount.Inc(getDeviceAuthPolicy(g.GameName), "dns_exploit")
 		} else {
-			//deviceId = g.verifyExLoginInfo(command, authToken)
-			if deviceId == 0 {
-				deviceAuth = true //return
+			var ok bool
+			if deviceId, deviceAuth, ok = g.checkDeviceAuth(command, authToken); !ok {
+				return
 			}
-			deviceAuth = true
 		}
 	} else if g.UnitCode == UnitCodeDS {
 		g.NeedsExploit = common.DoesGameNeedExploit(g.GameName)
@@ -268,10 +282,34 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 	g.ModuleName = "GPCM:" + strconv.FormatInt(int64(g.User.ProfileId), 10) + "*"
 	g.ModuleName += "/" + common.CalcFriendCodeString(g.User.ProfileId, g.User.GsbrCode[:4]) + "*"
 
+	// Restore the buddy list so friends who are already online can authorize before the console re-sends addbuddy
+	friendList, err := friends.GetFriends(ctx, g.User.ProfileId)
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to load friend list:", err)
+	}
+	for _, friend := range friendList {
+		if g.isFriendListFull() {
+			logging.Warn(g.ModuleName, "Stored friend list is larger than the game's roster, ignoring the newest entries")
+			break
+		}
+
+		if !g.isFriendAdded(friend.FriendId) {
+			g.FriendList = append(g.FriendList, friend.FriendId)
+		}
+	}
+
+	blockList, err := friends.GetBlocks(ctx, g.User.ProfileId)
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to load block list:", err)
+	} else {
+		g.BlockList = blockList
+	}
+
 	// Check to see if a session is already open with this profile ID
 	mutex.Lock() //PP take a look for openhost
 	otherSession, exists := sessions[g.User.ProfileId]
 	if exists {
+		otherSession.setDisconnectReason("forced_disconnect")
 		otherSession.replyError(ErrForcedDisconnect)
 		common.CloseConnection(ServerName, otherSession.ConnIndex)
 
@@ -304,9 +342,18 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 	g.LoggedIn = true
 	g.ModuleName = "GPCM:" + strconv.FormatInt(int64(g.User.ProfileId), 10)
 	g.ModuleName += "/" + common.CalcFriendCodeString(g.User.ProfileId, g.User.GsbrCode[:4])
+	logging.Notice(g.ModuleName, "Logged in as", aurora.BrightCyan(ingamesn), g.logFields())
+	g.startLoginSession()
 
 	// Notify QR2 of the login //PP
 	qr2.Login(g.User.ProfileId, gamecd, ingamesn, cfc, g.User.GsbrCode[:4], g.RemoteAddr, g.NeedsExploit, g.DeviceAuthenticated, g.User.Restricted, g.User.Trusted, g.User.OpenHost, ctgpver)
+	qr2.SetBlockList(g.User.ProfileId, g.BlockList)
+
+	events.Publish(events.PlayerLogin, g.GameName, events.LoginData{
+		PID:        g.User.ProfileId,
+		GameCode:   gamecd,
+		InGameName: ingamesn,
+	})
 
 	replyUserId := g.User.UserId
 	if g.UnitCode == UnitCodeDS {
@@ -324,14 +371,10 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 		"id":         command.OtherValues["id"],
 	}
 
-	if g.GameName == "mariokartwii" {
-		if motd, err := GetMessageOfTheDay(); err != nil {
-			logging.Info(g.ModuleName, err)
-		} else {
-			motdUTF16 := utf16.Encode([]rune(motd))
-			motdByteArray := common.UTF16ToByteArray(motdUTF16)
-			otherValues["wwfc_motd"] = common.Base64DwcEncoding.EncodeToString(motdByteArray)
-		}
+	if motd, ok := g.getMessageOfTheDay(); ok {
+		motdUTF16 := utf16.Encode([]rune(motd))
+		motdByteArray := common.UTF16ToByteArray(motdUTF16)
+		otherValues["wwfc_motd"] = common.Base64DwcEncoding.EncodeToString(motdByteArray)
 	}
 
 	payload := common.CreateGameSpyMessage(common.GameSpyCommand{
@@ -341,6 +384,10 @@ func (g *GameSpySession) login(command common.GameSpyCommand) {
 	})
 
 	common.SendPacket(ServerName, g.ConnIndex, []byte(payload))
+
+	// Sent after the login response, along with the rest of the write buffer
+	g.sendBlockList()
+	g.deliverFriendRequests(0)
 }
 
 func (g *GameSpySession) exLogin(command common.GameSpyCommand) {
@@ -351,8 +398,10 @@ func (g *GameSpySession) exLogin(command common.GameSpyCommand) {
 
 	deviceId := g.verifyExLoginInfo(command, g.AuthToken)
 	if deviceId == 0 {
+		deviceAuthCount.Inc(getDeviceAuthPolicy(g.GameName), "exlogin_rejected")
 		return
 	}
+	deviceAuthCount.Inc(getDeviceAuthPolicy(g.GameName), "exlogin_signed")
 
 	if !g.performLoginWithDatabase(g.User.UserId, g.User.GsbrCode, 0, deviceId) {
 		return
@@ -363,38 +412,45 @@ func (g *GameSpySession) exLogin(command common.GameSpyCommand) {
 }
 
 func (g *GameSpySession) verifyExLoginInfo(command common.GameSpyCommand, authToken string) uint32 {
+	deviceId, err := g.checkExLoginInfo(command, authToken)
+	if deviceId == 0 {
+		g.replyError(err)
+	}
+
+	return deviceId
+}
+
+// checkExLoginInfo verifies the payload version and signature, returning the error to send if the device ID is 0
+func (g *GameSpySession) checkExLoginInfo(command common.GameSpyCommand, authToken string) (uint32, GPError) {
 	payloadVer, payloadVerExists := command.OtherValues["payload_ver"]
 	signature, signatureExists := command.OtherValues["wwfc_sig"]
 	deviceId := uint32(0) //PP
 
 	if !payloadVerExists || payloadVer != "4" {
-		g.replyError(GPError{
+		return 0, GPError{
 			ErrorCode:   ErrLogin.ErrorCode,
 			ErrorString: "The payload version is invalid.",
 			Fatal:       false,
 			WWFCMessage: WWFCMsgPayloadInvalid,
-		})
-		return 0
+		}
 	}
 
 	if !signatureExists {
-		g.replyError(GPError{
+		return 0, GPError{
 			ErrorCode:   ErrLogin.ErrorCode,
 			ErrorString: "Missing authentication signature.",
 			Fatal:       false,
 			WWFCMessage: WWFCMsgUnknownLoginError,
-		})
-		return 0
+		}
 	}
 
 	if deviceId = verifySignature(g.ModuleName, authToken, signature); deviceId == 0 {
-		g.replyError(GPError{
+		return 0, GPError{
 			ErrorCode:   ErrLogin.ErrorCode,
 			ErrorString: "The authentication signature is invalid.",
 			Fatal:       false,
 			WWFCMessage: WWFCMsgUnknownLoginError,
-		})
-		return 0
+		}
 	}
 
 	g.DeviceId = deviceId
@@ -407,26 +463,24 @@ func (g *GameSpySession) verifyExLoginInfo(command common.GameSpyCommand, authTo
 			}
 
 			if strings.HasPrefix(g.HostPlatform, "Dolphin") {
-				g.replyError(GPError{
+				return 0, GPError{
 					ErrorCode:   ErrLogin.ErrorCode,
 					ErrorString: "Prohibited device ID used in signature.",
 					Fatal:       true,
 					WWFCMessage: WWFCMsgDolphinSetupRequired,
-				})
-			} else {
-				g.replyError(GPError{
-					ErrorCode:   ErrLogin.ErrorCode,
-					ErrorString: "Prohibited device ID used in signature.",
-					Fatal:       true,
-					WWFCMessage: WWFCMsgUnknownLoginError,
-				})
+				}
 			}
 
-			return 0
+			return 0, GPError{
+				ErrorCode:   ErrLogin.ErrorCode,
+				ErrorString: "Prohibited device ID used in signature.",
+				Fatal:       true,
+				WWFCMessage: WWFCMsgUnknownLoginError,
+			}
 		}
 	}
 
-	return deviceId
+	return deviceId, ErrNone
 }
 
 func (g *GameSpySession) performLoginWithDatabase(userId uint64, gsbrCode string, profileId uint32, deviceId uint32) bool {
@@ -436,7 +490,7 @@ func (g *GameSpySession) performLoginWithDatabase(userId uint64, gsbrCode string
 		ipAddress = ipAddress[:strings.Index(ipAddress, ":")]
 	}
 
-	user, err := database.LoginUserToGPCM(pool, ctx, userId, gsbrCode, profileId, deviceId, ipAddress, g.InGameName)
+	user, err := users.LoginUserToGPCM(ctx, userId, gsbrCode, profileId, deviceId, ipAddress, g.InGameName)
 	g.User = user
 
 	if err != nil {
@@ -476,6 +530,8 @@ func (g *GameSpySession) performLoginWithDatabase(userId uint64, gsbrCode string
 				ErrorString: "The profile is banned from the service.",
 				Fatal:       true,
 				WWFCMessage: WWFCMsgProfileBannedTOS,
+				Reason:      user.BanReason,
+				Expires:     user.BanExpires,
 			})
 		} else {
 			g.replyError(GPError{
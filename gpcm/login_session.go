@@ -0,0 +1,82 @@
+package gpcm
+
+import (
+	"strings"
+	"time"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+var loginSessions database.LoginSessionRepository
+
+// SetLoginSessionRepository sets the repository recording each login and how it ended
+func SetLoginSessionRepository(repository database.LoginSessionRepository) {
+	loginSessions = repository
+}
+
+// startLoginSession records the login. Must not be called with the mutex held.
+func (g *GameSpySession) startLoginSession() {
+	ipAddress := g.RemoteAddr
+	if index := strings.LastIndex(ipAddress, ":"); index != -1 {
+		ipAddress = ipAddress[:index]
+	}
+
+	sessionId, err := loginSessions.StartLoginSession(ctx, database.LoginSession{
+		ProfileId:    g.User.ProfileId,
+		GameName:     g.GameName,
+		GameCode:     g.GameCode,
+		HostPlatform: g.HostPlatform,
+		NgDeviceId:   g.User.NgDeviceId,
+		IpAddress:    ipAddress,
+		LoginAt:      time.Now(),
+	})
+	if err != nil {
+		logging.Error(g.ModuleName, "Failed to record login session:", err)
+		return
+	}
+
+	mutex.Lock()
+	g.LoginSessionId = sessionId
+	mutex.Unlock()
+}
+
+// setDisconnectReason keeps the first reason given, since a kick is followed by the connection closing.
+// Must be called with the mutex held.
+func (g *GameSpySession) setDisconnectReason(reason string) {
+	if g.DisconnectReason == "" {
+		g.DisconnectReason = reason
+	}
+}
+
+// endLoginSession records the logout. Must not be called with the mutex held.
+func (g *GameSpySession) endLoginSession() {
+	mutex.Lock()
+	sessionId := g.LoginSessionId
+	reason := g.DisconnectReason
+	mutex.Unlock()
+
+	if sessionId == 0 {
+		return
+	}
+
+	// Nothing on our side asked for the disconnect
+	if reason == "" {
+		reason = "network"
+	}
+
+	if err := loginSessions.EndLoginSession(ctx, sessionId, reason); err != nil {
+		logging.Error(g.ModuleName, "Failed to record logout:", err)
+	}
+}
+
+// closeOpenLoginSessions ends the sessions of a previous run whose state wasn't saved
+func closeOpenLoginSessions() {
+	closed, err := loginSessions.CloseOpenLoginSessions(ctx, "server_restart")
+	if err != nil {
+		logging.Error("GPCM", "Failed to close open login sessions:", err)
+	} else if closed != 0 {
+		logging.Notice("GPCM", "Closed", aurora.Cyan(closed), "login sessions left open by the last run")
+	}
+}
@@ -0,0 +1,45 @@
+package gpcm
+
+// SessionInfo is a snapshot of a logged in player's GPCM session
+type SessionInfo struct {
+	GameName            string `json:"game"`
+	GameCode            string `json:"game_code"`
+	InGameName          string `json:"name"`
+	RemoteAddr          string `json:"address"`
+	HostPlatform        string `json:"host_platform"`
+	Region              byte   `json:"region"`
+	Language            byte   `json:"language"`
+	DeviceAuthenticated bool   `json:"device_authenticated"`
+	Status              string `json:"status"`
+	LocString           string `json:"loc_string"`
+	FriendCount         int    `json:"friend_count"`
+	FriendLimit         int    `json:"friend_limit"`
+	FriendListFull      bool   `json:"friend_list_full"`
+}
+
+// GetSessionInfo returns the GPCM session of the profile, if it is logged in
+func GetSessionInfo(profileID uint32) (SessionInfo, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	session, exists := sessions[profileID]
+	if !exists || !session.LoggedIn {
+		return SessionInfo{}, false
+	}
+
+	return SessionInfo{
+		GameName:            session.GameName,
+		GameCode:            session.GameCode,
+		InGameName:          session.InGameName,
+		RemoteAddr:          session.RemoteAddr,
+		HostPlatform:        session.HostPlatform,
+		Region:              session.Region,
+		Language:            session.Language,
+		DeviceAuthenticated: session.DeviceAuthenticated,
+		Status:              session.Status,
+		LocString:           session.LocString,
+		FriendCount:         len(session.FriendList),
+		FriendLimit:         getFriendLimit(session.GameName),
+		FriendListFull:      session.isFriendListFull(),
+	}, true
+}
@@ -2,16 +2,15 @@ package gpcm
 
 import (
 	"context"
-	"encoding/gob"
-	"fmt"
 	"os"
 	"strings"
+	"time"
 	"wwfc/common"
 	"wwfc/database"
+	"wwfc/events"
 	"wwfc/logging"
 	"wwfc/qr2"
 
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/logrusorgru/aurora/v3"
 	"github.com/sasha-s/go-deadlock"
 )
@@ -40,12 +39,17 @@ type GameSpySession struct {
 	DeviceId          uint32
 	HostPlatform      string
 	UnitCode          byte
+	// Whether the client is running the WiiLink WFC payload
+	Patched bool
+	// Whether the payload reported it can display wwfc_announce messages
+	CanShowAnnouncements bool
 
 	StatusSet      bool
 	Status         string
 	LocString      string
 	FriendList     []uint32
 	AuthFriendList []uint32
+	BlockList      []uint32
 	// For syncing with local GS SDK buddy list
 	RecvStatusFromList []uint32
 
@@ -55,13 +59,18 @@ type GameSpySession struct {
 
 	NeedsExploit bool
 
+	// Row in login_sessions, and why the connection is being closed if the server closed it
+	LoginSessionId   int64
+	DisconnectReason string
+
 	ReadBuffer  []byte
 	WriteBuffer string
 }
 
 var (
-	ctx  = context.Background()
-	pool *pgxpool.Pool
+	ctx     = context.Background()
+	users   database.UserRepository
+	friends database.FriendRepository
 	// I would use a sync.Map instead of the map mutex combo, but this performs better.
 	sessions            = map[uint32]*GameSpySession{}
 	sessionsByConnIndex = map[uint64]*GameSpySession{}
@@ -76,21 +85,27 @@ func StartServer(reload bool) {
 	// Get config
 	config := common.GetConfig()
 
-	// Start SQL
-	dbString := fmt.Sprintf("postgres://%s:%s@%s/%s", config.Username, config.Password, config.DatabaseAddress, config.DatabaseName)
-	dbConf, err := pgxpool.ParseConfig(dbString)
-	if err != nil {
-		panic(err)
-	}
+	allowDefaultDolphinKeys = config.AllowDefaultDolphinKeys
+	setDeviceAuthPolicies(config)
+	friendRequestExpiry = time.Duration(config.FriendRequestExpiry) * 24 * time.Hour
 
-	pool, err = pgxpool.ConnectConfig(ctx, dbConf)
-	if err != nil {
-		panic(err)
+	loadErrorMessages()
+	go announcementLoop()
+
+	if err := ReloadMessagesOfTheDay(); err != nil {
+		logging.Error("GPCM", "Failed to load messages of the day:", err)
 	}
+	go motdLoop()
 
-	database.UpdateTables(pool, ctx)
+	if removed, err := friends.DeleteExpiredFriendRequests(ctx); err != nil {
+		logging.Error("GPCM", "Failed to delete expired friend requests:", err)
+	} else if removed != 0 {
+		logging.Notice("GPCM", "Deleted", aurora.Cyan(removed), "expired friend requests")
+	}
 
-	allowDefaultDolphinKeys = config.AllowDefaultDolphinKeys
+	if !reload {
+		closeOpenLoginSessions()
+	}
 
 	if reload {
 		err := loadState()
@@ -103,6 +118,16 @@ func StartServer(reload bool) {
 	}
 }
 
+// SetUserRepository sets the repository used for profile lookups and logins
+func SetUserRepository(repository database.UserRepository) {
+	users = repository
+}
+
+// SetFriendRepository sets the repository used to persist buddy lists
+func SetFriendRepository(repository database.FriendRepository) {
+	friends = repository
+}
+
 func Shutdown() {
 	err := saveState()
 	if err != nil {
@@ -111,6 +136,18 @@ func Shutdown() {
 	logging.Notice("GPCM", "Saved", aurora.Cyan(len(sessions)), "sessions")
 }
 
+// logFields returns the structured log fields identifying this session
+func (g *GameSpySession) logFields() logging.Fields {
+	fields := logging.Fields{"conn": g.ConnIndex}
+	if g.User.ProfileId != 0 {
+		fields["pid"] = g.User.ProfileId
+	}
+	if g.GameName != "" {
+		fields["game"] = g.GameName
+	}
+	return fields
+}
+
 func CloseConnection(index uint64) {
 	mutex.Lock()
 	session := sessionsByConnIndex[index]
@@ -121,14 +158,19 @@ func CloseConnection(index uint64) {
 		return
 	}
 
-	logging.Notice(session.ModuleName, "Connection closed")
+	logging.Notice(session.ModuleName, "Connection closed", session.logFields())
 
 	if session.LoggedIn {
+		events.Publish(events.PlayerLogout, session.GameName, events.LoginData{
+			PID: session.User.ProfileId,
+		})
+
 		qr2.Logout(session.User.ProfileId)
 		if session.QR2IP != 0 {
 			qr2.ProcessGPStatusUpdate(session.User.ProfileId, session.QR2IP, "0")
 		}
 		session.sendLogoutStatus()
+		session.endLoginSession()
 	}
 
 	mutex.Lock()
@@ -234,7 +276,12 @@ func HandlePacket(index uint64, data []byte) {
 	commands = session.handleCommand("ka", commands, func(command common.GameSpyCommand) {
 		common.SendPacket(ServerName, session.ConnIndex, []byte(`\ka\\final\`))
 	})
-	commands = session.handleCommand("login", commands, session.login)
+	commands = session.handleCommand("login", commands, func(command common.GameSpyCommand) {
+		session.login(command)
+		if session.LoggedIn {
+			loginCount.Inc("success", "")
+		}
+	})
 	commands = session.handleCommand("wwfc_exlogin", commands, session.exLogin)
 	commands = session.ignoreCommand("logout", commands)
 
@@ -250,6 +297,8 @@ func HandlePacket(index uint64, data []byte) {
 	commands = session.handleCommand("addbuddy", commands, session.addFriend)
 	commands = session.handleCommand("delbuddy", commands, session.removeFriend)
 	commands = session.handleCommand("authadd", commands, session.authAddFriend)
+	commands = session.handleCommand("addblock", commands, session.addBlock)
+	commands = session.handleCommand("removeblock", commands, session.removeBlock)
 	commands = session.handleCommand("bm", commands, session.bestieMessage)
 	commands = session.handleCommand("getprofile", commands, session.getProfile)
 
@@ -305,41 +354,32 @@ func (g *GameSpySession) ignoreCommand(name string, commands []common.GameSpyCom
 	return unhandled
 }
 
-func saveState() error {
-	file, err := os.OpenFile("state/gpcm_sessions.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-
-	encoder := gob.NewEncoder(file)
+func init() {
+	common.RegisterStateSection("gpcm_sessions", 1, func() interface{} { return &map[uint32]*GameSpySession{} }, nil)
+}
 
+func saveState() error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	err = encoder.Encode(sessions)
-	file.Close()
-	return err
+	return common.SaveStateSection("gpcm_sessions", sessions)
 }
 
 func loadState() error {
-	file, err := os.Open("state/gpcm_sessions.gob")
-	if err != nil {
-		return err
-	}
-
-	decoder := gob.NewDecoder(file)
-
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	err = decoder.Decode(&sessions)
-	file.Close()
+	err := common.LoadStateSection("gpcm_sessions", &sessions)
 	if err != nil {
 		return err
 	}
 
 	for _, session := range sessions {
 		sessionsByConnIndex[session.ConnIndex] = session
+
+		if session.trimFriendLists() {
+			logging.Warn(session.ModuleName, "Trimmed friend list to the game's roster size")
+		}
 	}
 
 	return nil
@@ -204,6 +204,12 @@ func (g *GameSpySession) bestieMessage(command common.GameSpyCommand) {
 		return
 	}
 
+	if g.isBlockedWith(toSession) {
+		logging.Notice(g.ModuleName, "Dropped message to", aurora.Cyan(toProfileId), "because one player has blocked the other")
+		sendMessageToSessionBuffer("1", uint32(toProfileId), g, resvDenyMsg)
+		return
+	}
+
 	sameAddress := strings.Split(g.RemoteAddr, ":")[0] == strings.Split(toSession.RemoteAddr, ":")[0]
 
 	if cmd == common.MatchReservation {
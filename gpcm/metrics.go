@@ -0,0 +1,17 @@
+package gpcm
+
+import "wwfc/metrics"
+
+var (
+	loginCount      = metrics.NewCounter("wwfc_gpcm_logins_total", "GPCM login attempts, by outcome and GP error code.", "result", "error_code")
+	deviceAuthCount = metrics.NewCounter("wwfc_gpcm_device_auth_total", "Device authentication checks, by policy and the path taken.", "policy", "result")
+)
+
+func init() {
+	metrics.NewGaugeFunc("wwfc_gpcm_sessions", "GPCM sessions currently logged in.", "", func() map[string]float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return map[string]float64{"": float64(len(sessions))}
+	})
+}
@@ -2,15 +2,76 @@ package gpcm
 
 import (
 	"os"
+	"sync"
+	"time"
+	"wwfc/database"
+	"wwfc/logging"
 )
 
-var motdFilepath = "./motd.txt"
+// How often the cached messages of the day are refreshed from the database
+const motdRefreshInterval = time.Minute
 
-func GetMessageOfTheDay() (string, error) {
-	contents, err := os.ReadFile(motdFilepath)
+var (
+	// Legacy message shown to Mario Kart Wii players when no database entry matches
+	motdFilepath = "./motd.txt"
+
+	motds     database.MotdRepository
+	motdMutex sync.RWMutex
+	// Entries that were active at the last refresh
+	motdCache []database.Motd
+	motdFile  string
+)
+
+// SetMotdRepository sets the repository holding the messages of the day
+func SetMotdRepository(repository database.MotdRepository) {
+	motds = repository
+}
+
+// ReloadMessagesOfTheDay refreshes the cache from the database and motd.txt
+func ReloadMessagesOfTheDay() error {
+	entries, err := motds.GetActiveMotds(ctx)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	file := ""
+	if contents, err := os.ReadFile(motdFilepath); err == nil {
+		file = string(contents)
+	}
+
+	motdMutex.Lock()
+	defer motdMutex.Unlock()
+
+	motdCache = entries
+	motdFile = file
+	return nil
+}
+
+func motdLoop() {
+	for {
+		time.Sleep(motdRefreshInterval)
+
+		if err := ReloadMessagesOfTheDay(); err != nil {
+			logging.Error("GPCM", "Failed to reload messages of the day:", err)
+		}
+	}
+}
+
+// getMessageOfTheDay picks the active entry with the highest priority that targets this session
+func (g *GameSpySession) getMessageOfTheDay() (string, bool) {
+	motdMutex.RLock()
+	defer motdMutex.RUnlock()
+
+	// The cache is sorted by priority, then newest first
+	for _, motd := range motdCache {
+		if motd.Matches(g.GameName, g.Region, g.Language, g.HostPlatform) {
+			return motd.Message, true
+		}
+	}
+
+	if g.GameName == "mariokartwii" && motdFile != "" {
+		return motdFile, true
 	}
 
-	return string(contents), nil
+	return "", false
 }
@@ -0,0 +1,49 @@
+package gpcm
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+	"wwfc/database"
+	"wwfc/database/databasetest"
+)
+
+func TestMessageOfTheDay(t *testing.T) {
+	repository := databasetest.NewMotdRepository()
+	motds = repository
+	motdFilepath = filepath.Join(t.TempDir(), "motd.txt")
+	defer func() {
+		motds = nil
+		motdFilepath = "./motd.txt"
+		motdCache = nil
+	}()
+
+	later := time.Now().Add(time.Hour)
+	region := byte(1)
+	for _, motd := range []database.Motd{
+		{Message: "everyone", Priority: 0},
+		{Message: "mkw europe", GameName: "mariokartwii", Region: &region, Priority: 1},
+		{Message: "not started", Priority: 2, StartsAt: &later},
+	} {
+		if _, err := repository.AddMotd(ctx, motd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ReloadMessagesOfTheDay(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		session  GameSpySession
+		expected string
+	}{
+		{GameSpySession{GameName: "mariokartwii", Region: 1}, "mkw europe"},
+		{GameSpySession{GameName: "mariokartwii", Region: 2}, "everyone"},
+		{GameSpySession{GameName: "smashbrawlx", Region: 1}, "everyone"},
+	} {
+		if message, ok := test.session.getMessageOfTheDay(); !ok || message != test.expected {
+			t.Errorf("%s region %d got %q, expected %q", test.session.GameName, test.session.Region, message, test.expected)
+		}
+	}
+}
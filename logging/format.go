@@ -0,0 +1,177 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+type entry struct {
+	time      time.Time
+	level     int
+	module    string
+	arguments []any
+	fields    Fields
+}
+
+var levelTags = map[int]struct {
+	name  string
+	short string
+	color func(any) aurora.Value
+}{
+	LevelNotice: {"notice", "N", aurora.BrightGreen},
+	LevelError:  {"error", "E", aurora.BrightRed},
+	LevelWarn:   {"warn", "W", aurora.BrightYellow},
+	LevelInfo:   {"info", "I", aurora.BrightCyan},
+}
+
+func newEntry(t time.Time, level int, module string, arguments []any) entry {
+	e := entry{time: t, level: level, module: module}
+
+	for _, argument := range arguments {
+		if fields, ok := argument.(Fields); ok {
+			if e.fields == nil {
+				e.fields = Fields{}
+			}
+			for key, value := range fields {
+				e.fields[key] = value
+			}
+			continue
+		}
+
+		e.arguments = append(e.arguments, argument)
+	}
+
+	return e
+}
+
+func (e entry) message(color bool) string {
+	parts := make([]string, len(e.arguments))
+	for i, argument := range e.arguments {
+		parts[i] = sprint(argument, color)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// splitModule separates the module name from the context that callers append to it,
+// e.g. "GPCM:1234" becomes "GPCM" and "1234"
+func (e entry) splitModule() (string, string) {
+	module, context, _ := strings.Cut(e.module, ":")
+	return module, context
+}
+
+func (e entry) sortedFieldKeys() []string {
+	keys := make([]string, 0, len(e.fields))
+	for key := range e.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e entry) format(format string, color bool) []byte {
+	switch format {
+	case "json":
+		return e.formatJSON()
+	case "logfmt":
+		return e.formatLogfmt()
+	}
+
+	return e.formatText(color)
+}
+
+func (e entry) formatText(color bool) []byte {
+	var b strings.Builder
+	b.WriteString(e.time.Format("2006/01/02 15:04:05 "))
+
+	tag := levelTags[e.level]
+	if color {
+		b.WriteString(tag.color(tag.short + "[" + e.module + "]").String())
+	} else {
+		b.WriteString(tag.short + "[" + e.module + "]")
+	}
+
+	b.WriteString(": ")
+	b.WriteString(e.message(color))
+
+	for _, key := range e.sortedFieldKeys() {
+		b.WriteByte(' ')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(e.fields[key]))
+	}
+
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func (e entry) formatJSON() []byte {
+	module, context := e.splitModule()
+
+	var b strings.Builder
+	b.WriteString(`{"time":`)
+	writeJSON(&b, e.time.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, levelTags[e.level].name)
+	b.WriteString(`,"module":`)
+	writeJSON(&b, module)
+	if context != "" {
+		b.WriteString(`,"context":`)
+		writeJSON(&b, context)
+	}
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, e.message(false))
+
+	for _, key := range e.sortedFieldKeys() {
+		b.WriteByte(',')
+		writeJSON(&b, key)
+		b.WriteByte(':')
+		writeJSON(&b, plainValue(e.fields[key]))
+	}
+
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+func writeJSON(b *strings.Builder, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(data)
+}
+
+func (e entry) formatLogfmt() []byte {
+	module, context := e.splitModule()
+
+	var b strings.Builder
+	b.WriteString("time=" + e.time.Format(time.RFC3339Nano))
+	b.WriteString(" level=" + levelTags[e.level].name)
+	b.WriteString(" module=" + logfmtValue(module))
+	if context != "" {
+		b.WriteString(" context=" + logfmtValue(context))
+	}
+	b.WriteString(" msg=" + logfmtValue(e.message(false)))
+
+	for _, key := range e.sortedFieldKeys() {
+		b.WriteString(" " + key + "=" + logfmtValue(e.fields[key]))
+	}
+
+	b.WriteByte('\n')
+	return []byte(b.String())
+}
+
+func logfmtValue(value any) string {
+	str := sprint(value, false)
+	if str == "" || strings.ContainsAny(str, " \"=\t\r\n") {
+		return strconv.Quote(str)
+	}
+
+	return str
+}
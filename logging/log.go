@@ -6,99 +6,221 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/logrusorgru/aurora/v3"
 )
 
+const (
+	LevelNone   = 0
+	LevelNotice = 1
+	LevelError  = 2
+	LevelWarn   = 3
+	LevelInfo   = 4
+)
+
+// Fields can be passed as an argument to any log function to attach structured fields to the entry.
+// Common keys are "pid" (profile ID), "conn" (connection index) and "game".
+type Fields map[string]any
+
+type sink struct {
+	writer io.Writer
+	color  bool
+}
+
 var (
-	logDir   = "./logs"
-	logLevel = 0
+	logDir       = "./logs"
+	logLevel     = 0
+	moduleLevels = map[string]int{}
+	logFormat    = "text"
+	rotation     Rotation
+
+	outputMutex sync.Mutex
+	outputs     = []sink{{writer: os.Stdout, color: isTerminal(os.Stdout)}}
+	logFile     *rotatingFile
 )
 
+var levelNames = map[string]int{
+	"none":   LevelNone,
+	"notice": LevelNotice,
+	"error":  LevelError,
+	"warn":   LevelWarn,
+	"info":   LevelInfo,
+}
+
+// ParseLevel accepts a level name (none, notice, error, warn, info) or its number
+func ParseLevel(level string) (int, error) {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if value, ok := levelNames[level]; ok {
+		return value, nil
+	}
+
+	value, err := strconv.Atoi(level)
+	if err != nil || value < LevelNone || value > LevelInfo {
+		return 0, errors.New("invalid log level: " + level)
+	}
+
+	return value, nil
+}
+
 func SetLevel(level int) {
 	logLevel = level
 }
 
+// SetModuleLevel overrides the log level for one module, such as "QR2" or "GPCM"
+func SetModuleLevel(module string, level int) {
+	moduleLevels[strings.ToUpper(module)] = level
+}
+
+// SetFormat selects the output format: text, json or logfmt
+func SetFormat(format string) error {
+	switch format {
+	case "", "text":
+		logFormat = "text"
+	case "json", "logfmt":
+		logFormat = format
+	default:
+		return errors.New("invalid log format provided")
+	}
+
+	return nil
+}
+
+// SetRotation configures log file rotation. Must be called before SetOutput.
+func SetRotation(r Rotation) {
+	rotation = r
+}
+
 func SetOutput(output string) error {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+
 	switch output {
 	case "None":
-		log.SetOutput(io.Discard)
+		outputs = nil
 	case "StdOut":
-		log.SetOutput(os.Stdout)
+		outputs = []sink{{writer: os.Stdout, color: isTerminal(os.Stdout)}}
 	case "StdOutAndFile":
-		if err := os.MkdirAll(logDir, 0700); err != nil {
-			return err
-		}
-
-		time := time.Now()
-		logFilePath := time.Format(logDir + "/2006-01-02-15-04-05.log")
-
-		file, err := os.OpenFile(logFilePath, os.O_WRONLY|os.O_CREATE, 0400)
+		file, err := openRotatingFile(logDir, rotation)
 		if err != nil {
 			return err
 		}
 
-		log.SetOutput(io.MultiWriter(os.Stdout, file))
+		logFile = file
+		outputs = []sink{{writer: os.Stdout, color: isTerminal(os.Stdout)}, {writer: file}}
 	default:
 		return errors.New("invalid output value provided")
 	}
 
+	// Anything still using the standard logger ends up in the same place
+	writers := []io.Writer{}
+	for _, output := range outputs {
+		writers = append(writers, output.writer)
+	}
+	log.SetOutput(io.MultiWriter(writers...))
+
 	return nil
 }
 
-func Notice(module string, arguments ...any) {
-	if logLevel < 1 {
-		return
-	}
-
-	var finalStr string
-	for _, argument := range arguments {
-		finalStr += fmt.Sprint(argument)
-		finalStr += " "
+// isTerminal reports whether the file is a character device, so colors are only written to a TTY
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
 	}
 
-	log.Printf(aurora.BrightGreen("N[%s]").String()+": %s", module, finalStr)
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-func Error(module string, arguments ...any) {
-	if logLevel < 2 {
-		return
-	}
+// enabled checks the level against the module's override, falling back to the global level.
+// Module names are matched on the part before the first ':' and then before the first '/',
+// so "QR2/MSG:p1234" uses the level for "QR2/MSG" if set, otherwise the level for "QR2".
+func enabled(module string, level int) bool {
+	if len(moduleLevels) != 0 {
+		name := strings.ToUpper(module)
+		if index := strings.IndexByte(name, ':'); index != -1 {
+			name = name[:index]
+		}
 
-	var finalStr string
-	for _, argument := range arguments {
-		finalStr += fmt.Sprint(argument)
-		finalStr += " "
+		if moduleLevel, ok := moduleLevels[name]; ok {
+			return moduleLevel >= level
+		}
+
+		if index := strings.IndexByte(name, '/'); index != -1 {
+			if moduleLevel, ok := moduleLevels[name[:index]]; ok {
+				return moduleLevel >= level
+			}
+		}
 	}
 
-	log.Printf(aurora.BrightRed("E[%s]").String()+": %s", module, finalStr)
+	return logLevel >= level
 }
 
-func Warn(module string, arguments ...any) {
-	if logLevel < 3 {
+func write(level int, module string, arguments []any) {
+	if !enabled(module, level) {
 		return
 	}
 
-	var finalStr string
-	for _, argument := range arguments {
-		finalStr += fmt.Sprint(argument)
-		finalStr += " "
+	entry := newEntry(time.Now(), level, module, arguments)
+
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	var plain, colored []byte
+	for _, output := range outputs {
+		if output.color {
+			if colored == nil {
+				colored = entry.format(logFormat, true)
+			}
+			output.writer.Write(colored)
+		} else {
+			if plain == nil {
+				plain = entry.format(logFormat, false)
+			}
+			output.writer.Write(plain)
+		}
 	}
+}
+
+func Notice(module string, arguments ...any) {
+	write(LevelNotice, module, arguments)
+}
+
+func Error(module string, arguments ...any) {
+	write(LevelError, module, arguments)
+}
 
-	log.Printf(aurora.BrightYellow("W[%s]").String()+": %s", module, finalStr)
+func Warn(module string, arguments ...any) {
+	write(LevelWarn, module, arguments)
 }
 
 func Info(module string, arguments ...any) {
-	if logLevel < 4 {
-		return
+	write(LevelInfo, module, arguments)
+}
+
+// plainValue strips aurora colors from an argument
+func plainValue(argument any) any {
+	for {
+		value, ok := argument.(aurora.Value)
+		if !ok {
+			return argument
+		}
+		argument = value.Value()
 	}
+}
 
-	var finalStr string
-	for _, argument := range arguments {
-		finalStr += fmt.Sprint(argument)
-		finalStr += " "
+func sprint(argument any, color bool) string {
+	if color {
+		return fmt.Sprint(argument)
 	}
 
-	log.Printf(aurora.BrightCyan("I[%s]").String()+": %s", module, finalStr)
+	return fmt.Sprint(plainValue(argument))
 }
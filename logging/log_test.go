@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+func TestModuleLevels(t *testing.T) {
+	defer func() {
+		logLevel = 0
+		moduleLevels = map[string]int{}
+	}()
+
+	SetLevel(LevelInfo)
+	SetModuleLevel("QR2", LevelWarn)
+	SetModuleLevel("QR2/MSG", LevelError)
+
+	tests := []struct {
+		module string
+		level  int
+		want   bool
+	}{
+		{"GPCM:1234", LevelInfo, true},
+		{"QR2:127.0.0.1:27900", LevelInfo, false},
+		{"QR2:127.0.0.1:27900", LevelWarn, true},
+		{"QR2/GPStatus:1234", LevelWarn, true},
+		{"QR2/MSG:p1234", LevelWarn, false},
+		{"qr2/msg", LevelError, true},
+	}
+
+	for _, test := range tests {
+		if got := enabled(test.module, test.level); got != test.want {
+			t.Errorf("enabled(%q, %d) = %v, want %v", test.module, test.level, got, test.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e := newEntry(at, LevelWarn, "GPCM:1234", []any{"Player", aurora.Cyan("Mario"), "kicked", Fields{"pid": 1234, "game": "mariokartwii"}})
+
+	text := string(e.format("text", false))
+	if text != "2024/01/02 03:04:05 W[GPCM:1234]: Player Mario kicked game=mariokartwii pid=1234\n" {
+		t.Errorf("unexpected text output: %q", text)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(e.format("json", false), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["module"] != "GPCM" || decoded["context"] != "1234" || decoded["msg"] != "Player Mario kicked" || decoded["pid"] != float64(1234) {
+		t.Errorf("unexpected json output: %v", decoded)
+	}
+
+	logfmt := string(e.format("logfmt", false))
+	if logfmt != "time=2024-01-02T03:04:05Z level=warn module=GPCM context=1234 msg=\"Player Mario kicked\" game=mariokartwii pid=1234\n" {
+		t.Errorf("unexpected logfmt output: %q", logfmt)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	// Leftovers from earlier runs, oldest first
+	for _, name := range []string{"2000-01-01-00-00-00.log", "2000-01-02-00-00-00.log", "2000-01-03-00-00-00.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := openRotatingFile(dir, Rotation{MaxSize: 16, MaxFiles: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for i := 0; i < 3; i++ {
+		if _, err := file.Write([]byte("0123456789\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	if len(names) != 3 || strings.HasPrefix(names[0], "2000-01-01") || strings.HasPrefix(names[0], "2000-01-02") {
+		t.Errorf("unexpected log files after rotation: %v", names)
+	}
+}
@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rotation controls when the log file is replaced and how many old files are kept.
+// A zero value for any field disables that limit.
+type Rotation struct {
+	// Start a new file once the current one reaches this many bytes
+	MaxSize int64
+	// Start a new file after this much time has passed since the current one was opened
+	Interval time.Duration
+	// Delete the oldest files beyond this count
+	MaxFiles int
+	// Delete files last written longer ago than this
+	MaxAge time.Duration
+}
+
+// rotatingFile is only written to with outputMutex held
+type rotatingFile struct {
+	dir      string
+	rotation Rotation
+	file     *os.File
+	size     int64
+	opened   time.Time
+}
+
+func openRotatingFile(dir string, rotation Rotation) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	r := &rotatingFile{dir: dir, rotation: rotation}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	now := time.Now()
+	path := filepath.Join(r.dir, now.Format("2006-01-02-15-04-05")+".log")
+
+	// Don't reuse a file if rotating more than once a second
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = filepath.Join(r.dir, now.Format("2006-01-02-15-04-05")+"-"+strconv.Itoa(i)+".log")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	r.file = file
+	r.size = 0
+	r.opened = now
+	r.prune()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if (r.rotation.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.rotation.MaxSize) ||
+		(r.rotation.Interval > 0 && time.Since(r.opened) >= r.rotation.Interval) {
+		r.file.Close()
+		r.file = nil
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// prune deletes old log files according to the retention settings, never touching the current file
+func (r *rotatingFile) prune() {
+	if r.rotation.MaxFiles <= 0 && r.rotation.MaxAge <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+
+	current := filepath.Base(r.file.Name())
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") || entry.Name() == current {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	// File names start with the time they were opened, so this sorts oldest first
+	sort.Slice(names, func(i, j int) bool {
+		timeI, countI := splitLogName(names[i])
+		timeJ, countJ := splitLogName(names[j])
+		if timeI != timeJ {
+			return timeI < timeJ
+		}
+		return countI < countJ
+	})
+
+	for i, name := range names {
+		remove := r.rotation.MaxFiles > 0 && len(names)-i >= r.rotation.MaxFiles
+		if !remove && r.rotation.MaxAge > 0 {
+			if info, err := os.Stat(filepath.Join(r.dir, name)); err == nil {
+				remove = time.Since(info.ModTime()) > r.rotation.MaxAge
+			}
+		}
+
+		if remove {
+			os.Remove(filepath.Join(r.dir, name))
+		}
+	}
+}
+
+// splitLogName splits "2006-01-02-15-04-05-1.log" into its timestamp and the count added when rotating more than once a second
+func splitLogName(name string) (string, int) {
+	name = strings.TrimSuffix(name, ".log")
+	timestamp := "2006-01-02-15-04-05"
+	if len(name) <= len(timestamp) {
+		return name, 0
+	}
+
+	count, err := strconv.Atoi(name[len(timestamp)+1:])
+	if err != nil {
+		return name, 0
+	}
+
+	return name[:len(timestamp)], count
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net"
@@ -11,10 +12,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 	"wwfc/api"
+	"wwfc/bridge"
 	"wwfc/common"
+	"wwfc/database"
 	"wwfc/gamestats"
 	"wwfc/gpcm"
 	"wwfc/gpsp"
@@ -25,18 +29,37 @@ import (
 	"wwfc/sake"
 	"wwfc/serverbrowser"
 
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/logrusorgru/aurora/v3"
 )
 
 var (
-	config = common.GetConfig()
+	config common.Config
+	// The backend's database pool, closed once the servers have shut down
+	databasePool *pgxpool.Pool
 )
 
 func main() {
-	logging.SetLevel(*config.LogLevel)
+	config = common.GetConfig()
+	configureLogging()
 
 	args := os.Args[1:]
 
+	if len(args) > 0 && args[0] == "migrate" {
+		migrateMain(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "moderator" {
+		moderatorMain(args[1:])
+		return
+	}
+
+	if len(args) > 0 && args[0] == "state" {
+		stateMain(args[1:])
+		return
+	}
+
 	// Separate frontend and backend into two separate processes.
 	// This is to allow restarting the backend without closing all connections.
 	noSignal := false
@@ -61,13 +84,35 @@ func main() {
 	}
 }
 
-type RPCPacket struct {
-	Server  string
-	Index   uint64
-	Address string
-	Data    []byte
+// configureLogging applies the log level, format and rotation settings from the config.
+// The output itself is chosen separately by the frontend and backend.
+func configureLogging() {
+	logging.SetLevel(*config.LogLevel)
+
+	for _, module := range config.LogModuleLevels {
+		level, err := logging.ParseLevel(module.Level)
+		if err != nil {
+			logging.Error("MAIN", "Invalid log level for module", aurora.Cyan(module.Name), "-", err)
+			continue
+		}
+
+		logging.SetModuleLevel(module.Name, level)
+	}
+
+	if err := logging.SetFormat(config.LogFormat); err != nil {
+		logging.Error("MAIN", err)
+	}
+
+	logging.SetRotation(logging.Rotation{
+		MaxSize:  config.LogRotateSize * 1024 * 1024,
+		Interval: time.Duration(config.LogRotateInterval) * time.Hour,
+		MaxFiles: config.LogMaxFiles,
+		MaxAge:   time.Duration(config.LogMaxAge) * 24 * time.Hour,
+	})
 }
 
+type RPCPacket struct{}
+
 // backendMain starts all the servers and creates an RPC server to communicate with the frontend
 func backendMain(noSignal, noReload bool) {
 	sigExit := make(chan os.Signal, 1)
@@ -93,11 +138,48 @@ func backendMain(noSignal, noReload bool) {
 		uuid = loadUuidFile()
 	}
 
+	if uuid != "" {
+		// Starting clean resets the frontend's connections, but that's better than running on state that doesn't match this build
+		if err := common.ReadStateSnapshot(common.StateSnapshotPath, uuid); err != nil {
+			logging.Error("BACKEND", "Failed to load state snapshot, starting clean:", err)
+			uuid = ""
+		}
+	}
+
 	reload, err := common.VerifyState(uuid)
 	if err != nil {
 		panic(err)
 	}
 
+	err = common.LoadTokenKeys(config.TokenKeyFile, time.Duration(*config.TokenKeyRotation)*time.Hour, time.Duration(config.TokenKeyGrace)*time.Hour)
+	if err != nil {
+		logging.Error("BACKEND", "Failed to load token keys:", err)
+		os.Exit(1)
+	}
+	go common.RotateTokenKeysLoop()
+
+	pool, err := database.Connect(context.Background(), config)
+	if err != nil {
+		logging.Error("BACKEND", "Failed to connect to the database:", err)
+		os.Exit(1)
+	}
+
+	if _, err := database.MigrateUp(pool, context.Background()); err != nil {
+		logging.Error("BACKEND", "Failed to migrate database:", err)
+		os.Exit(1)
+	}
+
+	databasePool = pool
+	repository := database.NewPostgresRepository(pool)
+	api.SetRepositories(repository, repository, repository, repository, repository, repository, repository, repository, repository)
+	gpcm.SetUserRepository(repository)
+	gpcm.SetFriendRepository(repository)
+	gpcm.SetAnnouncementRepository(repository)
+	gpcm.SetMotdRepository(repository)
+	gpcm.SetLoginSessionRepository(repository)
+	gamestats.SetUserRepository(repository)
+	sake.SetFriendInfoRepository(repository)
+
 	wg := &sync.WaitGroup{}
 	actions := []func(bool){nas.StartServer, gpcm.StartServer, qr2.StartServer, gpsp.StartServer, serverbrowser.StartServer, sake.StartServer, natneg.StartServer, api.StartServer, gamestats.StartServer}
 	wg.Add(len(actions))
@@ -123,6 +205,11 @@ func backendMain(noSignal, noReload bool) {
 		}
 	}()
 
+	go func() {
+		err := common.ServeFrontend(handleFrontendFrame)
+		logging.Error("BACKEND", "Bridge to the frontend closed:", err)
+	}()
+
 	logging.Notice("BACKEND", "Listening on", aurora.BrightCyan(address))
 
 	common.Ready()
@@ -159,57 +246,70 @@ func loadUuidFile() string {
 	return string(uuid)
 }
 
-// RPCPacket.NewConnection is called by the frontend to notify the backend of a new connection
-func (r *RPCPacket) NewConnection(args RPCPacket, _ *struct{}) error {
-	switch args.Server {
+// handleFrontendFrame passes a frame from the frontend to its server. Frames for one connection are
+// handled in order.
+func handleFrontendFrame(frame bridge.Frame) {
+	switch frame.Type {
+	case bridge.FrameConnect:
+		newConnection(frame.Server, frame.Index, frame.Address)
+	case bridge.FramePacket:
+		handlePacket(frame.Server, frame.Index, frame.Address, frame.Data)
+	case bridge.FrameClose:
+		closeConnection(frame.Server, frame.Index)
+	case bridge.FrameDatagram:
+		err := common.HandleUDPPacket(frame.Server, frame.Address, frame.Data)
+		if err != nil {
+			logging.Error("BACKEND", "Failed to handle datagram for", aurora.Cyan(frame.Server), "-", err)
+		}
+	}
+}
+
+// newConnection notifies a server of a new connection
+func newConnection(server string, index uint64, address string) {
+	switch server {
 	case "serverbrowser":
-		serverbrowser.NewConnection(args.Index, args.Address)
+		serverbrowser.NewConnection(index, address)
 	case "gpcm":
-		gpcm.NewConnection(args.Index, args.Address)
+		gpcm.NewConnection(index, address)
 	case "gpsp":
-		gpsp.NewConnection(args.Index, args.Address)
+		gpsp.NewConnection(index, address)
 	case "gamestats":
-		gamestats.NewConnection(args.Index, args.Address)
+		gamestats.NewConnection(index, address)
 	}
-
-	return nil
 }
 
-// RPCPacket.HandlePacket is called by the frontend to forward a packet to the backend
-func (r *RPCPacket) HandlePacket(args RPCPacket, _ *struct{}) error {
-	switch args.Server {
+// handlePacket forwards a packet to a server
+func handlePacket(server string, index uint64, address string, data []byte) {
+	switch server {
 	case "serverbrowser":
-		serverbrowser.HandlePacket(args.Index, args.Data, args.Address)
+		serverbrowser.HandlePacket(index, data, address)
 	case "gpcm":
-		gpcm.HandlePacket(args.Index, args.Data)
+		gpcm.HandlePacket(index, data)
 	case "gpsp":
-		gpsp.HandlePacket(args.Index, args.Data)
+		gpsp.HandlePacket(index, data)
 	case "gamestats":
-		gamestats.HandlePacket(args.Index, args.Data)
+		gamestats.HandlePacket(index, data)
 	}
-
-	return nil
 }
 
-// RPCPacket.closeConnection is called by the frontend to notify the backend of a closed connection
-func (r *RPCPacket) CloseConnection(args RPCPacket, _ *struct{}) error {
-	switch args.Server {
+// closeConnection notifies a server of a closed connection
+func closeConnection(server string, index uint64) {
+	switch server {
 	case "serverbrowser":
-		serverbrowser.CloseConnection(args.Index)
+		serverbrowser.CloseConnection(index)
 	case "gpcm":
-		gpcm.CloseConnection(args.Index)
+		gpcm.CloseConnection(index)
 	case "gpsp":
-		gpsp.CloseConnection(args.Index)
+		gpsp.CloseConnection(index)
 	case "gamestats":
-		gamestats.CloseConnection(args.Index)
+		gamestats.CloseConnection(index)
 	}
-
-	return nil
 }
 
 // RPCPacket.Shutdown is called by the frontend to shutdown the backend
 func (r *RPCPacket) Shutdown(stateUuid string, _ *struct{}) error {
 	if stateUuid == "" {
+		databasePool.Close()
 		os.Exit(0)
 		return nil
 	}
@@ -226,6 +326,17 @@ func (r *RPCPacket) Shutdown(stateUuid string, _ *struct{}) error {
 
 	wg.Wait()
 
+	// The servers may write to the database while shutting down
+	databasePool.Close()
+
+	err := common.WriteStateSnapshot(common.StateSnapshotPath, stateUuid)
+	if err != nil {
+		logging.Error("BACKEND", "Failed to write state snapshot:", err)
+		// The next backend will start clean
+		os.Remove("state/uuid.txt")
+		os.Exit(1)
+	}
+
 	stateFile, err := os.OpenFile("state/uuid.txt", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		panic(err)
@@ -245,29 +356,59 @@ func (r *RPCPacket) Shutdown(stateUuid string, _ *struct{}) error {
 	return nil
 }
 
+// Datagrams held for the backend per UDP port while it reloads. Beyond this they're dropped.
+const frontendUDPQueueSize = 16384
+
+// Packets from the backend waiting to be written to one client. A client this far behind is disconnected.
+const frontendClientQueueSize = 64
+
 type serverInfo struct {
 	rpcName  string
 	protocol string
 	port     int
 }
 
-type RPCFrontendPacket struct {
-	Server string
-	Index  uint64
-	Data   []byte
+type RPCFrontendPacket struct{}
+
+// frontendConn is a client connection open on the frontend
+type frontendConn struct {
+	conn net.Conn
+	// Holds the send time of each packet the backend hasn't acknowledged yet
+	window chan time.Time
+	// Packets from the backend, written to the client in order. nil closes the connection.
+	send chan []byte
+	done chan struct{}
+}
+
+// udpServer is a UDP socket open on the frontend
+type udpServer struct {
+	conn   net.PacketConn
+	window chan time.Time
 }
 
 var (
 	rpcClient *rpc.Client
+	backend   *bridge.Conn
+	// The bridge opened by the most recently started backend
+	latestBridge      *bridge.Conn
+	latestBridgeMutex sync.Mutex
 
+	// Locked for writing while there's no backend to send to, which holds up anything sent to it.
 	// This mutex could be locked for a very long time, don't use deadlock detection
-	rpcMutex sync.Mutex
+	rpcMutex sync.RWMutex
 
+	// Frames sent to the backend that it hasn't acknowledged yet
 	rpcBusyCount sync.WaitGroup
-	backendReady = make(chan struct{})
-	frontendUuid string
+	// Set while the backend is expected to disconnect
+	backendStopping atomic.Bool
+	backendReady    = make(chan struct{})
+	frontendUuid    string
 
-	connections = map[string]map[uint64]*net.Conn{}
+	connections      = map[string]map[uint64]*frontendConn{}
+	connectionsMutex sync.Mutex
+
+	udpServers      = map[string]*udpServer{}
+	udpServersMutex sync.RWMutex
 
 	integrated = false
 )
@@ -289,6 +430,8 @@ func frontendMain(noSignal, noBackend bool) {
 		logging.Error("FRONTEND", err)
 	}
 
+	registerFrontendMetrics()
+
 	rpcMutex.Lock()
 
 	startFrontendServer()
@@ -304,10 +447,17 @@ func frontendMain(noSignal, noBackend bool) {
 		{rpcName: "gpcm", protocol: "tcp", port: 29900},
 		{rpcName: "gpsp", protocol: "tcp", port: 29901},
 		{rpcName: "gamestats", protocol: "tcp", port: 29920},
+		{rpcName: "qr2", protocol: "udp", port: 27900},
+		{rpcName: "natneg", protocol: "udp", port: 27901},
 	}
 
 	for _, server := range servers {
-		connections[server.rpcName] = map[uint64]*net.Conn{}
+		if server.protocol == "udp" {
+			go frontendListenUDP(server)
+			continue
+		}
+
+		connections[server.rpcName] = map[uint64]*frontendConn{}
 		go frontendListen(server)
 	}
 
@@ -322,11 +472,12 @@ func frontendMain(noSignal, noBackend bool) {
 		return
 	}
 
+	backendStopping.Store(true)
 	rpcClient.Call("RPCPacket.Shutdown", "", nil)
 	rpcClient.Close()
 }
 
-// startFrontendServer starts the frontend RPC server.
+// startFrontendServer starts the frontend RPC server, which also accepts the backend's bridge stream.
 func startFrontendServer() {
 	rpc.Register(&RPCFrontendPacket{})
 	address := config.FrontendAddress
@@ -347,7 +498,28 @@ func startFrontendServer() {
 				continue
 			}
 
-			go rpc.ServeConn(conn)
+			go func() {
+				bridgeConn, rpcConn, err := bridge.Accept(conn)
+				if err != nil {
+					conn.Close()
+					return
+				}
+
+				if bridgeConn == nil {
+					rpc.ServeConn(rpcConn)
+					return
+				}
+
+				bridgeConn.OnFlush = func(frames int) {
+					frontendBridgeBatch.Observe(float64(frames))
+				}
+
+				latestBridgeMutex.Lock()
+				latestBridge = bridgeConn
+				latestBridgeMutex.Unlock()
+
+				readBackend(bridgeConn)
+			}()
 		}
 	}()
 }
@@ -389,10 +561,27 @@ func waitForBackend() {
 	<-backendReady
 	backendReady = make(chan struct{})
 
+	// The backend opens its bridge before it reports ready, but it may not have been accepted yet
+	previous := backend
+	for backend == previous {
+		latestBridgeMutex.Lock()
+		backend = latestBridge
+		latestBridgeMutex.Unlock()
+
+		if backend == previous {
+			<-time.After(10 * time.Millisecond)
+		}
+	}
+
 	for {
 		client, err := rpc.Dial("tcp", config.FrontendBackendAddress)
 		if err == nil {
 			rpcClient = client
+			if !frontendBusyStart.IsZero() {
+				frontendBackendBusy.Add(time.Since(frontendBusyStart).Seconds())
+				frontendBusyStart = time.Time{}
+			}
+			backendStopping.Store(false)
 			rpcMutex.Unlock()
 
 			logging.Notice("FRONTEND", "Connected to backend")
@@ -404,6 +593,81 @@ func waitForBackend() {
 	}
 }
 
+// sendToBackend sends a frame over the bridge, waiting while the backend reloads.
+// The frame counts as busy until the backend acknowledges it.
+func sendToBackend(frame bridge.Frame) error {
+	rpcMutex.RLock()
+	rpcBusyCount.Add(1)
+	conn := backend
+	rpcMutex.RUnlock()
+
+	err := conn.Send(frame)
+	if err != nil {
+		rpcBusyCount.Done()
+	}
+	return err
+}
+
+// readBackend handles the frames a backend sends over the bridge until it disconnects
+func readBackend(conn *bridge.Conn) {
+	for {
+		frame, err := conn.Receive()
+		if err != nil {
+			conn.Close()
+			if !backendStopping.Load() {
+				logging.Error("FRONTEND", "Lost connection to backend:", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		switch frame.Type {
+		case bridge.FrameAck:
+			handleBackendAck(frame)
+		case bridge.FramePacket:
+			sendToClient(frame)
+		case bridge.FrameClose:
+			closeClient(frame)
+		case bridge.FrameDatagram:
+			sendDatagram(frame)
+		}
+	}
+}
+
+func handleBackendAck(frame bridge.Frame) {
+	rpcBusyCount.Done()
+
+	if len(frame.Data) != 1 {
+		return
+	}
+
+	var window chan time.Time
+	switch frame.Data[0] {
+	case bridge.FramePacket:
+		connectionsMutex.Lock()
+		if client := connections[frame.Server][frame.Index]; client != nil {
+			window = client.window
+		}
+		connectionsMutex.Unlock()
+
+	case bridge.FrameDatagram:
+		udpServersMutex.RLock()
+		if server := udpServers[frame.Server]; server != nil {
+			window = server.window
+		}
+		udpServersMutex.RUnlock()
+	}
+
+	if window != nil {
+		// Acks for a connection or UDP server arrive in the order its frames were sent
+		select {
+		case sent := <-window:
+			frontendBridgeAck.Observe(time.Since(sent).Seconds(), frame.Server)
+		default:
+		}
+	}
+}
+
 // frontendListen listens on the specified port and forwards each packet to the backend
 func frontendListen(server serverInfo) {
 	address := *config.GameSpyAddress + ":" + strconv.Itoa(server.port)
@@ -438,26 +702,102 @@ func frontendListen(server serverInfo) {
 	}
 }
 
+// frontendListenUDP receives datagrams on the specified port and forwards them to the backend.
+// Datagrams are queued while the backend reloads, and dropped if the queue fills up.
+func frontendListenUDP(server serverInfo) {
+	address := *config.GameSpyAddress + ":" + strconv.Itoa(server.port)
+	conn, err := net.ListenPacket(server.protocol, address)
+	if err != nil {
+		logging.Error("FRONTEND", "Failed to listen on", aurora.BrightCyan(address))
+		return
+	}
+
+	udp := &udpServer{
+		conn:   conn,
+		window: make(chan time.Time, bridge.DatagramWindow),
+	}
+
+	udpServersMutex.Lock()
+	udpServers[server.rpcName] = udp
+	udpServersMutex.Unlock()
+
+	logging.Notice("FRONTEND", "Listening on", aurora.BrightCyan(address), "for", aurora.BrightCyan(server.rpcName))
+
+	queue := make(chan bridge.Frame, frontendUDPQueueSize)
+	go forwardUDPPackets(udp, queue)
+
+	for {
+		buffer := make([]byte, 1024)
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil || n == 0 {
+			continue
+		}
+
+		select {
+		case queue <- bridge.Frame{Type: bridge.FrameDatagram, Server: server.rpcName, Address: addr.String(), Data: buffer[:n]}:
+		default:
+			frontendPacketCount.Inc(server.rpcName, "dropped")
+		}
+	}
+}
+
+// forwardUDPPackets forwards queued datagrams to the backend, waiting while it reloads or falls behind
+func forwardUDPPackets(server *udpServer, queue chan bridge.Frame) {
+	for frame := range queue {
+		server.window <- time.Now()
+
+		frontendPacketCount.Inc(frame.Server, "to_backend")
+		err := sendToBackend(frame)
+		if err != nil {
+			<-server.window
+			logging.Error("FRONTEND", "Failed to forward UDP packet to backend:", err)
+		}
+	}
+}
+
+// sendDatagram sends a datagram from the backend out of the frontend's UDP socket
+func sendDatagram(frame bridge.Frame) {
+	udpServersMutex.RLock()
+	server := udpServers[frame.Server]
+	udpServersMutex.RUnlock()
+
+	if server == nil {
+		logging.Error("FRONTEND", "No socket for", aurora.Cyan(frame.Server))
+		return
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", frame.Address)
+	if err != nil {
+		logging.Error("FRONTEND", "Invalid datagram address:", err)
+		return
+	}
+
+	server.conn.WriteTo(frame.Data, addr)
+	frontendPacketCount.Inc(frame.Server, "to_client")
+}
+
 // handleConnection forwards packets between the frontend and backend
 func handleConnection(server serverInfo, conn net.Conn, index uint64) {
 	defer conn.Close()
 
-	rpcMutex.Lock()
-	rpcBusyCount.Add(1)
-	pConn := &conn
-	connections[server.rpcName][index] = pConn
-	rpcMutex.Unlock()
-
-	err := rpcClient.Call("RPCPacket.NewConnection", RPCPacket{Server: server.rpcName, Index: index, Address: conn.RemoteAddr().String(), Data: []byte{}}, nil)
+	client := &frontendConn{
+		conn:   conn,
+		window: make(chan time.Time, bridge.ConnectionWindow),
+		send:   make(chan []byte, frontendClientQueueSize),
+		done:   make(chan struct{}),
+	}
+	defer close(client.done)
+	go client.writeLoop()
 
-	rpcBusyCount.Done()
+	connectionsMutex.Lock()
+	connections[server.rpcName][index] = client
+	connectionsMutex.Unlock()
+	frontendConnections.Inc(server.rpcName)
 
+	err := sendToBackend(bridge.Frame{Type: bridge.FrameConnect, Server: server.rpcName, Index: index, Address: conn.RemoteAddr().String()})
 	if err != nil {
 		logging.Error("FRONTEND", "Failed to forward new connection to backend:", err)
-
-		rpcMutex.Lock()
-		delete(connections[server.rpcName], index)
-		rpcMutex.Unlock()
+		removeConnection(server.rpcName, index, client)
 		return
 	}
 
@@ -472,79 +812,99 @@ func handleConnection(server serverInfo, conn net.Conn, index uint64) {
 			continue
 		}
 
-		rpcMutex.Lock()
-		rpcBusyCount.Add(1)
-		rpcMutex.Unlock()
+		// Wait for the backend to catch up with this connection
+		client.window <- time.Now()
 
 		// Forward the packet to the backend
-		err = rpcClient.Call("RPCPacket.HandlePacket", RPCPacket{Server: server.rpcName, Index: index, Address: conn.RemoteAddr().String(), Data: buffer[:n]}, nil)
-
-		rpcBusyCount.Done()
-
+		frontendPacketCount.Inc(server.rpcName, "to_backend")
+		err = sendToBackend(bridge.Frame{Type: bridge.FramePacket, Server: server.rpcName, Index: index, Address: conn.RemoteAddr().String(), Data: buffer[:n]})
 		if err != nil {
 			logging.Error("FRONTEND", "Failed to forward packet to backend:", err)
-			if err == rpc.ErrShutdown {
-				os.Exit(1)
-			}
 			break
 		}
 	}
 
-	rpcMutex.Lock()
-	if connections[server.rpcName][index] != pConn {
-		rpcMutex.Unlock()
+	if !removeConnection(server.rpcName, index, client) {
 		return
 	}
 
-	rpcBusyCount.Add(1)
-	delete(connections[server.rpcName], index)
-	rpcMutex.Unlock()
-
-	err = rpcClient.Call("RPCPacket.CloseConnection", RPCPacket{Server: server.rpcName, Index: index, Address: conn.RemoteAddr().String(), Data: []byte{}}, nil)
-
-	rpcBusyCount.Done()
-
+	err = sendToBackend(bridge.Frame{Type: bridge.FrameClose, Server: server.rpcName, Index: index})
 	if err != nil {
 		logging.Error("FRONTEND", "Failed to forward close connection to backend:", err)
-		if err == rpc.ErrShutdown {
-			os.Exit(1)
-		}
 	}
 }
 
-var (
-	ErrBadIndex = errors.New("incorrect connection index")
-	ErrorBusy   = errors.New("backend is busy")
-)
+// removeConnection removes a connection from the map, unless it was already removed
+func removeConnection(server string, index uint64, client *frontendConn) bool {
+	connectionsMutex.Lock()
+	defer connectionsMutex.Unlock()
 
-// RPCFrontendPacket.SendPacket is called by the backend to send a packet to a connection
-func (r *RPCFrontendPacket) SendPacket(args RPCFrontendPacket, _ *struct{}) error {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
+	if connections[server][index] != client {
+		return false
+	}
+
+	delete(connections[server], index)
+	frontendConnections.Dec(server)
+	return true
+}
+
+// writeLoop writes the packets the backend sends to the client
+func (c *frontendConn) writeLoop() {
+	for {
+		select {
+		case data := <-c.send:
+			if data == nil {
+				c.conn.Close()
+				return
+			}
+
+			c.conn.Write(data)
 
-	conn := connections[args.Server][args.Index]
-	if conn == nil {
-		return ErrBadIndex
+		case <-c.done:
+			return
+		}
 	}
+}
 
-	_, err := (*conn).Write(args.Data)
+// sendToClient queues a packet from the backend for a client connection
+func sendToClient(frame bridge.Frame) {
+	connectionsMutex.Lock()
+	client := connections[frame.Server][frame.Index]
+	connectionsMutex.Unlock()
 
-	return err
+	if client == nil {
+		return
+	}
+
+	select {
+	case client.send <- frame.Data:
+		frontendPacketCount.Inc(frame.Server, "to_client")
+	default:
+		logging.Warn("FRONTEND", "Disconnecting", aurora.BrightCyan(client.conn.RemoteAddr().String()), "for not reading")
+		frontendPacketCount.Inc(frame.Server, "dropped")
+		client.conn.Close()
+	}
 }
 
-// RPCFrontendPacket.CloseConnection is called by the backend to close a connection
-func (r *RPCFrontendPacket) CloseConnection(args RPCFrontendPacket, _ *struct{}) error {
-	rpcMutex.Lock()
-	defer rpcMutex.Unlock()
+// closeClient closes a client connection once the packets queued for it have been written
+func closeClient(frame bridge.Frame) {
+	connectionsMutex.Lock()
+	client := connections[frame.Server][frame.Index]
+	connectionsMutex.Unlock()
 
-	conn := connections[args.Server][args.Index]
-	if conn == nil {
-		return ErrBadIndex
+	if client == nil {
+		return
 	}
 
-	return (*conn).Close()
+	select {
+	case client.send <- nil:
+	default:
+		client.conn.Close()
+	}
 }
 
+var ErrorBusy = errors.New("backend is busy")
+
 // RPCFrontendPacket.ReloadBackend is called by an external program to reload the backend
 func (r *RPCFrontendPacket) ReloadBackend(_ struct{}, _ *struct{}) error {
 	var stateUid string
@@ -572,7 +932,10 @@ func (r *RPCFrontendPacket) ShutdownBackend(_ struct{}, uuid *string) error {
 
 	// Lock indefinitely
 	rpcMutex.Lock()
+	frontendBusyStart = time.Now()
+	backendStopping.Store(true)
 
+	// Wait for the backend to handle everything already sent to it
 	rpcBusyCount.Wait()
 
 	if !integrated {
@@ -600,12 +963,15 @@ func (r *RPCFrontendPacket) VerifyState(uuid string, reload *bool) error {
 		logging.Notice("FRONTEND", "VerifyState: Resetting all connections")
 
 		// Close all connections
-		for _, server := range connections {
-			for index, conn := range server {
-				(*conn).Close()
+		connectionsMutex.Lock()
+		for name, server := range connections {
+			for index, client := range server {
+				client.conn.Close()
 				delete(server, index)
+				frontendConnections.Dec(name)
 			}
 		}
+		connectionsMutex.Unlock()
 
 		*reload = false
 		return nil
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"time"
+	"wwfc/metrics"
+)
+
+// The frontend keeps its metrics in their own registry. The server modules register theirs in the default
+// registry from init, so writing that here would repeat every module's families in /metrics.
+var (
+	frontendRegistry     = metrics.NewRegistry()
+	frontendPacketCount  *metrics.Counter
+	frontendConnections  *metrics.Gauge
+	frontendBridgeBatch  *metrics.Histogram
+	frontendBridgeAck    *metrics.Histogram
+	frontendBackendBusy  *metrics.Counter
+	frontendBusyStart    time.Time
+	frontendBatchBuckets = []float64{1, 2, 4, 8, 16, 32, 64, 128, 256, 512, 1024}
+	frontendAckBuckets   = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1}
+	frontendMetricsReady = false
+)
+
+func registerFrontendMetrics() {
+	frontendPacketCount = frontendRegistry.NewCounter("wwfc_frontend_packets_total", "Packets forwarded by the frontend, by server and direction.", "server", "direction")
+	frontendConnections = frontendRegistry.NewGauge("wwfc_frontend_connections", "TCP connections currently open on the frontend, by server.", "server")
+	frontendBridgeBatch = frontendRegistry.NewHistogram("wwfc_frontend_bridge_batch_frames", "Frames written to the backend per batch.", frontendBatchBuckets)
+	frontendBridgeAck = frontendRegistry.NewHistogram("wwfc_frontend_bridge_ack_seconds", "Time from forwarding a packet to the backend until the backend acknowledges it, by server. Includes time held while the backend reloads.", frontendAckBuckets, "server")
+	frontendBackendBusy = frontendRegistry.NewCounter("wwfc_frontend_backend_busy_seconds_total", "Time the frontend spent holding packets while the backend reloaded.")
+	frontendMetricsReady = true
+}
+
+// RPCFrontendPacket.GetMetrics is called by the backend to include the frontend's metrics in /metrics
+func (r *RPCFrontendPacket) GetMetrics(_ struct{}, reply *string) error {
+	var buffer bytes.Buffer
+	if frontendMetricsReady {
+		frontendRegistry.Write(&buffer)
+	}
+
+	*reply = buffer.String()
+	return nil
+}
@@ -0,0 +1,331 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// metric is anything that can write itself in the Prometheus text exposition format
+type metric interface {
+	write(w io.Writer)
+}
+
+// Registry is a set of metrics written together. The package level functions use DefaultRegistry.
+type Registry struct {
+	mutex   sync.Mutex
+	metrics []metric
+}
+
+// DefaultRegistry holds the metrics registered by the server modules
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mutex.Lock()
+	r.metrics = append(r.metrics, m)
+	r.mutex.Unlock()
+}
+
+// Write writes every metric in the registry in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mutex.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Write writes every metric in DefaultRegistry
+func Write(w io.Writer) {
+	DefaultRegistry.Write(w)
+}
+
+// vec holds one value per combination of label values
+type vec struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+
+	mutex  sync.Mutex
+	values map[string]float64
+}
+
+func newVec(name string, help string, kind string, labelNames []string) *vec {
+	return &vec{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		values:     map[string]float64{},
+	}
+}
+
+func (v *vec) key(labelValues []string) string {
+	if len(labelValues) != len(v.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labelNames), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+func (v *vec) add(delta float64, labelValues []string) {
+	key := v.key(labelValues)
+
+	v.mutex.Lock()
+	v.values[key] += delta
+	v.mutex.Unlock()
+}
+
+func (v *vec) set(value float64, labelValues []string) {
+	key := v.key(labelValues)
+
+	v.mutex.Lock()
+	v.values[key] = value
+	v.mutex.Unlock()
+}
+
+func (v *vec) write(w io.Writer) {
+	v.mutex.Lock()
+	values := make(map[string]float64, len(v.values))
+	for key, value := range v.values {
+		values[key] = value
+	}
+	v.mutex.Unlock()
+
+	writeHeader(w, v.name, v.help, v.kind)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.labelNames, splitKey(key, len(v.labelNames)), "", ""), formatValue(values[key]))
+	}
+}
+
+// Counter is a value that only goes up, optionally split by labels
+type Counter struct {
+	v *vec
+}
+
+func NewCounter(name string, help string, labelNames ...string) *Counter {
+	return DefaultRegistry.NewCounter(name, help, labelNames...)
+}
+
+func (r *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	counter := &Counter{v: newVec(name, help, "counter", labelNames)}
+	r.register(counter.v)
+	return counter
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.v.add(1, labelValues)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter " + c.v.name + " cannot decrease")
+	}
+
+	c.v.add(delta, labelValues)
+}
+
+// Gauge is a value that can go up and down, optionally split by labels
+type Gauge struct {
+	v *vec
+}
+
+func NewGauge(name string, help string, labelNames ...string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help, labelNames...)
+}
+
+func (r *Registry) NewGauge(name string, help string, labelNames ...string) *Gauge {
+	gauge := &Gauge{v: newVec(name, help, "gauge", labelNames)}
+	r.register(gauge.v)
+	return gauge
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.v.set(value, labelValues)
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.v.add(1, labelValues)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.v.add(-1, labelValues)
+}
+
+// gaugeFunc is a gauge computed when the metrics are scraped
+type gaugeFunc struct {
+	name      string
+	help      string
+	labelName string
+	collect   func() map[string]float64
+}
+
+// NewGaugeFunc registers a gauge computed at scrape time. The map returned by collect is keyed by the value of
+// labelName, or by "" if labelName is empty.
+func NewGaugeFunc(name string, help string, labelName string, collect func() map[string]float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, labelName, collect)
+}
+
+func (r *Registry) NewGaugeFunc(name string, help string, labelName string, collect func() map[string]float64) {
+	r.register(&gaugeFunc{name: name, help: help, labelName: labelName, collect: collect})
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	values := g.collect()
+
+	writeHeader(w, g.name, g.help, "gauge")
+	for _, key := range sortedKeys(values) {
+		labels := ""
+		if g.labelName != "" {
+			labels = formatLabels([]string{g.labelName}, []string{key}, "", "")
+		}
+
+		fmt.Fprintf(w, "%s%s %s\n", g.name, labels, formatValue(values[key]))
+	}
+}
+
+// Histogram counts observations into cumulative buckets, optionally split by labels
+type Histogram struct {
+	name       string
+	help       string
+	buckets    []float64
+	labelNames []string
+
+	mutex  sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets, labelNames...)
+}
+
+func (r *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	histogram := &Histogram{
+		name:       name,
+		help:       help,
+		buckets:    append([]float64{}, buckets...),
+		labelNames: labelNames,
+		series:     map[string]*histogramSeries{},
+	}
+
+	sort.Float64s(histogram.buckets)
+	r.register(histogram)
+	return histogram
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", h.name, len(h.labelNames), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, "\xff")
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	series := h.series[key]
+	if series == nil {
+		series = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			series.counts[i]++
+		}
+	}
+
+	series.count++
+	series.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	writeHeader(w, h.name, h.help, "histogram")
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		series := h.series[key]
+		labelValues := splitKey(key, len(h.labelNames))
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, labelValues, "le", formatValue(bound)), series.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labelNames, labelValues, "le", "+Inf"), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labelNames, labelValues, "", ""), formatValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labelNames, labelValues, "", ""), series.count)
+	}
+}
+
+func writeHeader(w io.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, strings.ReplaceAll(help, "\n", " "), name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names []string, values []string, extraName string, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+labelEscaper.Replace(extraValue)+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func splitKey(key string, count int) []string {
+	if count == 0 {
+		return nil
+	}
+
+	return strings.Split(key, "\xff")
+}
+
+func sortedKeys(values map[string]float64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	counter := NewCounter("test_requests_total", "Requests.", "action")
+	counter.Inc("login")
+	counter.Add(2, `say "hi"`)
+
+	histogram := NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+
+	NewGaugeFunc("test_sessions", "Sessions.", "", func() map[string]float64 {
+		return map[string]float64{"": 3}
+	})
+
+	var buffer bytes.Buffer
+	Write(&buffer)
+	output := buffer.String()
+
+	for _, line := range []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{action="login"} 1`,
+		`test_requests_total{action="say \"hi\""} 2`,
+		`test_latency_seconds_bucket{le="0.1"} 1`,
+		`test_latency_seconds_bucket{le="1"} 2`,
+		`test_latency_seconds_bucket{le="+Inf"} 2`,
+		"test_latency_seconds_count 2",
+		"test_sessions 3",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("missing line %q in output:\n%s", line, output)
+		}
+	}
+}
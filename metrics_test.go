@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"wwfc/metrics"
+)
+
+// /metrics is the backend's registry followed by the frontend's, and Prometheus rejects a scrape that
+// declares a family twice
+func TestMetricsFamiliesUnique(t *testing.T) {
+	registerFrontendMetrics()
+
+	var buffer bytes.Buffer
+	metrics.Write(&buffer)
+
+	var frontend string
+	if err := (&RPCFrontendPacket{}).GetMetrics(struct{}{}, &frontend); err != nil {
+		t.Fatal(err)
+	}
+	buffer.WriteString(frontend)
+
+	seen := map[string]bool{}
+	for _, line := range strings.Split(buffer.String(), "\n") {
+		if !strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+
+		if seen[line] {
+			t.Errorf("repeated metric family: %q", line)
+		}
+		seen[line] = true
+	}
+
+	if !seen["# TYPE wwfc_frontend_packets_total counter"] || !seen["# TYPE wwfc_gpcm_sessions gauge"] {
+		t.Errorf("missing frontend or backend metrics in output:\n%s", buffer.String())
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// migrateMain runs the "migrate [up|down [steps]|status]" subcommand
+func migrateMain(args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	ctx := context.Background()
+
+	pool, err := database.Connect(ctx, config)
+	if err != nil {
+		logging.Error("MIGRATE", "Failed to connect to the database:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	switch command {
+	case "up":
+		count, err := database.MigrateUp(pool, ctx)
+		if err != nil {
+			logging.Error("MIGRATE", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MIGRATE", "Applied", aurora.Cyan(count), "migrations")
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				logging.Error("MIGRATE", "Invalid number of steps:", aurora.Cyan(args[1]))
+				os.Exit(1)
+			}
+		}
+
+		count, err := database.MigrateDown(pool, ctx, steps)
+		if err != nil {
+			logging.Error("MIGRATE", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MIGRATE", "Reverted", aurora.Cyan(count), "migrations")
+
+	case "status":
+		statuses, err := database.GetMigrationStatus(pool, ctx)
+		if err != nil {
+			logging.Error("MIGRATE", err)
+			os.Exit(1)
+		}
+
+		for _, status := range statuses {
+			name := fmt.Sprintf("%04d_%s", status.Version, status.Name)
+			if status.Applied {
+				fmt.Println("applied", name, status.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				fmt.Println("pending", name)
+			}
+		}
+
+	default:
+		logging.Error("MIGRATE", "Unknown command:", aurora.Cyan(command), "- expected up, down or status")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"wwfc/database"
+	"wwfc/logging"
+
+	"github.com/logrusorgru/aurora/v3"
+)
+
+// moderatorMain runs the "moderator [add|list|remove|role|reset]" subcommand
+func moderatorMain(args []string) {
+	command := "list"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	ctx := context.Background()
+
+	pool, err := database.Connect(ctx, config)
+	if err != nil {
+		logging.Error("MODERATOR", "Failed to connect to the database:", err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+
+	// The moderators table may not exist yet on a fresh install
+	_, err = database.MigrateUp(pool, ctx)
+	if err != nil {
+		logging.Error("MODERATOR", "Failed to migrate database:", err)
+		os.Exit(1)
+	}
+
+	requireArgs := func(count int, usage string) {
+		if len(args) < count+1 {
+			logging.Error("MODERATOR", "Usage: wwfc moderator", command, usage)
+			os.Exit(1)
+		}
+	}
+
+	switch command {
+	case "add":
+		requireArgs(2, "<name> <viewer|moderator|admin>")
+
+		token, err := database.CreateModerator(pool, ctx, args[1], args[2])
+		if err != nil {
+			logging.Error("MODERATOR", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MODERATOR", "Created", aurora.Cyan(args[2]), aurora.Cyan(args[1]))
+		fmt.Println(token)
+
+	case "list":
+		moderators, err := database.GetModerators(pool, ctx)
+		if err != nil {
+			logging.Error("MODERATOR", err)
+			os.Exit(1)
+		}
+
+		for _, moderator := range moderators {
+			lastUsed := "never"
+			if moderator.LastUsedAt != nil {
+				lastUsed = moderator.LastUsedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Println(moderator.Name, moderator.Role, lastUsed)
+		}
+
+	case "remove":
+		requireArgs(1, "<name>")
+
+		err := database.DeleteModerator(pool, ctx, args[1])
+		if err != nil {
+			logging.Error("MODERATOR", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MODERATOR", "Removed", aurora.Cyan(args[1]))
+
+	case "role":
+		requireArgs(2, "<name> <viewer|moderator|admin>")
+
+		err := database.SetModeratorRole(pool, ctx, args[1], args[2])
+		if err != nil {
+			logging.Error("MODERATOR", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MODERATOR", "Changed role of", aurora.Cyan(args[1]), "to", aurora.Cyan(args[2]))
+
+	case "reset":
+		requireArgs(1, "<name>")
+
+		token, err := database.ResetModeratorToken(pool, ctx, args[1])
+		if err != nil {
+			logging.Error("MODERATOR", err)
+			os.Exit(1)
+		}
+
+		logging.Notice("MODERATOR", "Reset API token of", aurora.Cyan(args[1]))
+		fmt.Println(token)
+
+	default:
+		logging.Error("MODERATOR", "Unknown command:", aurora.Cyan(command), "- expected add, list, remove, role or reset")
+		os.Exit(1)
+	}
+}
@@ -87,6 +87,7 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 
 	reply := map[string]string{}
 	var response []byte
+	metricAction := "unknown"
 
 	if r.URL.String() == "/ac" {
 		action, ok := fields["action"]
@@ -98,6 +99,7 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 
 		switch strings.ToLower(action) {
 		case "acctcreate":
+			metricAction = "acctcreate"
 			reply = acctcreate()
 			break
 
@@ -118,10 +120,12 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 				ctgpver = fields["_ctgpver"]
 				fmt.Println("CTGP FOUND: ", ctgpver) //PP CTGP PP
 			}
+			metricAction = "login"
 			reply = login(moduleName, fields, isLocalhost, ctgpver)
 			break
 
 		case "svcloc":
+			metricAction = "svcloc"
 			reply = svcloc(fields)
 			break
 
@@ -141,6 +145,7 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 			return
 		}
 
+		metricAction = "profanity"
 		reply = handleProfanity(fields)
 	} else if r.URL.String() == "/download" {
 		action, ok := fields["action"]
@@ -159,6 +164,7 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 
 		switch strings.ToLower(action) {
 		case "count":
+			metricAction = "count"
 			response = []byte(dlsCount(fields))
 			break
 
@@ -174,6 +180,8 @@ func handleAuthRequest(moduleName string, w http.ResponseWriter, r *http.Request
 		w.Header().Set("X-DLS-Host", "http://127.0.0.1/")
 	}
 
+	authRequestCount.Inc(metricAction, reply["returncd"])
+
 	if len(response) == 0 {
 		param := url.Values{}
 		for key, value := range reply {
@@ -132,11 +132,29 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check for /api/v1/*
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		api.HandleV1(w, r)
+		return
+	}
+
+	// Check for /metrics
+	if r.URL.Path == "/metrics" {
+		api.HandleMetrics(w, r)
+		return
+	}
+
 	// Check for /api/groups
 	if r.URL.Path == "/api/groups" {
 		api.HandleGroups(w, r)
 		return
 	}
+	// Check for /api/events
+	if r.URL.Path == "/api/events" {
+		api.HandleEvents(w, r)
+		return
+	}
+
 	// Check for /api/json
 	if r.URL.Path == "/api/json" || r.URL.Path == "/json" {
 		api.HandleJson(w, r)
@@ -155,6 +173,24 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check for /api/bans
+	if r.URL.Path == "/api/bans" {
+		api.HandleBanHistory(w, r)
+		return
+	}
+
+	// Check for /api/ban/expiry
+	if r.URL.Path == "/api/ban/expiry" {
+		api.HandleBanExpiry(w, r)
+		return
+	}
+
+	// Check for /api/ban/appeal
+	if r.URL.Path == "/api/ban/appeal" {
+		api.HandleBanAppeal(w, r)
+		return
+	}
+
 	// Check for /api/unban
 	if r.URL.Path == "/api/unban" {
 		api.HandleUnban(w, r)
@@ -167,6 +203,18 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check for /api/audit
+	if r.URL.Path == "/api/audit" {
+		api.HandleAudit(w, r)
+		return
+	}
+
+	// Check for /api/moderators
+	if r.URL.Path == "/api/moderators" {
+		api.HandleModerators(w, r)
+		return
+	}
+
 	if r.URL.Path == "/api/trusted" {
 		api.HandleFetch(w, r)
 		return
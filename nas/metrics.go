@@ -0,0 +1,5 @@
+package nas
+
+import "wwfc/metrics"
+
+var authRequestCount = metrics.NewCounter("wwfc_nas_auth_requests_total", "NAS auth requests, by action and returncd.", "action", "returncd")
@@ -3,10 +3,8 @@ package natneg
 import (
 	"bytes"
 	"encoding/binary"
-	"encoding/gob"
 	"fmt"
 	"net"
-	"os"
 	"sync"
 	"time"
 	"wwfc/common"
@@ -87,31 +85,20 @@ var (
 	waitGroup  = sync.WaitGroup{}
 )
 
-func StartServer(reload bool) {
-	// Get config
-	config := common.GetConfig()
+func init() {
+	common.RegisterStateSection("natneg_sessions", 1, func() interface{} { return &map[uint32]*NATNEGSession{} }, nil)
+}
 
-	address := *config.GameSpyAddress + ":27901"
-	conn, err := net.ListenPacket("udp", address)
-	if err != nil {
-		panic(err)
-	}
+func StartServer(reload bool) {
+	// The frontend owns the socket so negotiations aren't dropped while the backend reloads
+	conn := common.ListenUDP("natneg")
 
 	natnegConn = conn
 	inShutdown = false
 
 	if reload {
 		// Load state
-		file, err := os.Open("state/natneg_sessions.gob")
-		if err != nil {
-			panic(err)
-		}
-
-		decoder := gob.NewDecoder(file)
-
-		err = decoder.Decode(&sessions)
-		file.Close()
-
+		err := common.LoadStateSection("natneg_sessions", &sessions)
 		if err != nil {
 			panic(err)
 		}
@@ -133,7 +120,7 @@ func StartServer(reload bool) {
 
 		// Close the listener when the application closes.
 		defer conn.Close()
-		logging.Notice("NATNEG", "Listening on", aurora.BrightCyan(address))
+		logging.Notice("NATNEG", "Receiving packets from the frontend")
 
 		for {
 			if inShutdown {
@@ -162,16 +149,7 @@ func Shutdown() {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	file, err := os.OpenFile("state/natneg_sessions.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		panic(err)
-	}
-
-	encoder := gob.NewEncoder(file)
-
-	err = encoder.Encode(sessions)
-	file.Close()
-
+	err := common.SaveStateSection("natneg_sessions", sessions)
 	if err != nil {
 		panic(err)
 	}
@@ -216,6 +194,7 @@ func handleConnection(conn net.PacketConn, addr net.Addr, buffer []byte) {
 				Clients: map[byte]*NATNEGClient{},
 			}
 			sessions[cookie] = session
+			sessionCount.Inc()
 
 			// Session has TTL of 30 seconds
 			time.AfterFunc(30*time.Second, func() {
@@ -0,0 +1,17 @@
+package natneg
+
+import "wwfc/metrics"
+
+var (
+	sessionCount = metrics.NewCounter("wwfc_natneg_sessions_total", "NATNEG sessions created.")
+	reportCount  = metrics.NewCounter("wwfc_natneg_reports_total", "NATNEG reports received, by result.", "result")
+)
+
+func init() {
+	metrics.NewGaugeFunc("wwfc_natneg_sessions", "NATNEG sessions currently open.", "", func() map[string]float64 {
+		mutex.RLock()
+		defer mutex.RUnlock()
+
+		return map[string]float64{"": float64(len(sessions))}
+	})
+}
@@ -3,6 +3,7 @@ package natneg
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"wwfc/logging"
 	"wwfc/qr2"
 
@@ -24,6 +25,7 @@ func (session *NATNEGSession) handleReport(conn net.PacketConn, addr net.Addr, b
 
 	moduleName := "NATNEG:" + fmt.Sprintf("%08x/", session.Cookie) + addr.String()
 	logging.Notice(moduleName, "Report from", aurora.BrightCyan(clientIndex), "result:", aurora.Cyan(result))
+	reportCount.Inc(strconv.Itoa(int(result)))
 
 	if client, exists := session.Clients[clientIndex]; exists {
 		client.Result[client.ConnectingIndex] = result
@@ -85,6 +85,16 @@ func (w *response) finishRequest() {
 	}
 }
 
+// Flush sends any buffered data to the client, for handlers that stream their response
+func (w *response) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(_http.StatusOK)
+	}
+
+	w.w.Flush()
+	w.cw.flush()
+}
+
 func (w *response) Header() _http.Header {
 	if w.cw.header == nil && w.wroteHeader && !w.cw.wroteHeader {
 		// Accessing the header between logically writing it
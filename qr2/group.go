@@ -3,14 +3,13 @@ package qr2
 import (
 	"encoding/base64"
 	"encoding/binary"
-	"encoding/gob"
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 	"wwfc/common"
+	"wwfc/events"
 	"wwfc/logging"
 
 	"github.com/logrusorgru/aurora/v3"
@@ -81,7 +80,16 @@ func processResvOK(moduleName string, matchVersion int, reservation common.Match
 		sender.GroupName = group.GroupName
 		groups[group.GroupName] = group
 
-		logging.Notice(moduleName, "Created new group", aurora.Cyan(group.GroupName))
+		logging.Notice(moduleName, "Created new group", aurora.Cyan(group.GroupName), logging.Fields{"pid": sender.Data["dwc_pid"], "game": group.GameName})
+		events.Publish(events.GroupCreated, group.GameName, events.GroupData{
+			GroupName: group.GroupName,
+			HostPID:   sender.Data["dwc_pid"],
+		})
+		events.Publish(events.PlayerJoined, group.GameName, events.GroupPlayerData{
+			GroupName: group.GroupName,
+			PID:       sender.Data["dwc_pid"],
+			JoinIndex: sender.Data["+joinindex"],
+		})
 	}
 
 	// Keep group ID updated
@@ -96,7 +104,7 @@ func processResvOK(moduleName string, matchVersion int, reservation common.Match
 		return true
 	}
 
-	logging.Notice(moduleName, "New player", aurora.BrightCyan(destination.Data["dwc_pid"]), "in group", aurora.Cyan(group.GroupName))
+	logging.Notice(moduleName, "New player", aurora.BrightCyan(destination.Data["dwc_pid"]), "in group", aurora.Cyan(group.GroupName), logging.Fields{"pid": destination.Data["dwc_pid"], "game": group.GameName})
 
 	group.LastJoinIndex++
 	destination.Data["+joinindex"] = strconv.Itoa(group.LastJoinIndex)
@@ -112,6 +120,12 @@ func processResvOK(moduleName string, matchVersion int, reservation common.Match
 	destination.groupPointer = group
 	destination.GroupName = group.GroupName
 
+	events.Publish(events.PlayerJoined, group.GameName, events.GroupPlayerData{
+		GroupName: group.GroupName,
+		PID:       destination.Data["dwc_pid"],
+		JoinIndex: destination.Data["+joinindex"],
+	})
+
 	return true
 }
 
@@ -245,6 +259,11 @@ func checkReservationAllowed(moduleName string, sender, destination *Session, jo
 		return ""
 	}
 
+	if sender.login.isBlocking(destination.login.ProfileID) || destination.login.isBlocking(sender.login.ProfileID) {
+		logging.Notice(moduleName, "Denied reservation between blocked players")
+		return "blocked"
+	}
+
 	if !sender.login.Restricted && !destination.login.Restricted {
 		return "ok"
 	}
@@ -445,6 +464,18 @@ func (g *Group) findNewServer() {
 		}
 	}
 
+	if server != g.server {
+		hostPID := ""
+		if server != nil {
+			hostPID = server.Data["dwc_pid"]
+		}
+
+		events.Publish(events.HostChanged, g.GameName, events.GroupData{
+			GroupName: g.GroupName,
+			HostPID:   hostPID,
+		})
+	}
+
 	g.server = server
 	g.updateMatchType()
 }
@@ -659,31 +690,20 @@ func GetGroups(gameNames []string, groupNames []string, sorted bool) []GroupInfo
 	return groupsCopy
 }
 
-// saveGroups saves the current groups state to disk.
+func init() {
+	common.RegisterStateSection("qr2_groups", 1, func() interface{} { return &map[string]*Group{} }, nil)
+}
+
+// saveGroups saves the current groups state to the state snapshot.
 // Expects the mutex to be locked.
 func saveGroups() error {
-	file, err := os.OpenFile("state/qr2_groups.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(groups)
-	file.Close()
-	return err
+	return common.SaveStateSection("qr2_groups", groups)
 }
 
-// loadGroups loads the groups state from disk.
+// loadGroups loads the groups state from the state snapshot.
 // Expects the mutex to be locked, and the sessions to already be loaded.
 func loadGroups() error {
-	file, err := os.Open("state/qr2_groups.gob")
-	if err != nil {
-		return err
-	}
-
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(&groups)
-	file.Close()
+	err := common.LoadStateSection("qr2_groups", &groups)
 	if err != nil {
 		return err
 	}
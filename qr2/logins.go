@@ -1,9 +1,8 @@
 package qr2
 
 import (
-	"encoding/gob"
-	"os"
 	"strconv"
+	"wwfc/common"
 )
 
 type LoginInfo struct {
@@ -20,6 +19,7 @@ type LoginInfo struct {
 	Trusted             bool
 	OpenHoster          bool
 	CTGPVER             string
+	BlockList           []uint32
 }
 
 var logins = map[uint32]*LoginInfo{}
@@ -46,6 +46,25 @@ func Login(profileID uint32, gameCode string, inGameName string, consoleFriendCo
 	//fmt.Println(logins[profileID])
 }
 
+// SetBlockList updates the profiles blocked by a logged in player, used to deny reservations between them
+func SetBlockList(profileID uint32, blockList []uint32) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if login, exists := logins[profileID]; exists {
+		login.BlockList = append([]uint32{}, blockList...)
+	}
+}
+
+func (login *LoginInfo) isBlocking(profileID uint32) bool {
+	for _, blocked := range login.BlockList {
+		if blocked == profileID {
+			return true
+		}
+	}
+	return false
+}
+
 func SetDeviceAuthenticated(profileID uint32) {
 	mutex.Lock()
 	defer mutex.Unlock()
@@ -72,29 +91,18 @@ func Logout(profileID uint32) {
 	delete(logins, profileID)
 }
 
-// Save logins to a file. Expects the mutex to be locked.
-func saveLogins() error {
-	file, err := os.OpenFile("state/qr2_logins.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
+func init() {
+	common.RegisterStateSection("qr2_logins", 1, func() interface{} { return &map[uint32]*LoginInfo{} }, nil)
+}
 
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(logins)
-	file.Close()
-	return err
+// Save logins to the state snapshot. Expects the mutex to be locked.
+func saveLogins() error {
+	return common.SaveStateSection("qr2_logins", logins)
 }
 
-// Load logins from a file. Expects the mutex to be locked, and the sessions to already be loaded.
+// Load logins from the state snapshot. Expects the mutex to be locked, and the sessions to already be loaded.
 func loadLogins() error {
-	file, err := os.Open("state/qr2_logins.gob")
-	if err != nil {
-		return err
-	}
-
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(&logins)
-	file.Close()
+	err := common.LoadStateSection("qr2_logins", &logins)
 	if err != nil {
 		return err
 	}
@@ -118,3 +126,54 @@ func loadLogins() error {
 
 	return nil
 }
+
+// PlayerSessionInfo is a snapshot of a player's QR2 login and the group they are in
+type PlayerSessionInfo struct {
+	GameCode    string   `json:"game_code"`
+	InGameName  string   `json:"name"`
+	PublicIP    string   `json:"public_ip"`
+	Restricted  bool     `json:"restricted"`
+	HasSession  bool     `json:"has_session"`
+	GroupName   string   `json:"group,omitempty"`
+	JoinIndex   string   `json:"join_index,omitempty"`
+	MiiNames    []string `json:"mii_names"`
+	OpenHoster  bool     `json:"open_host"`
+	CTGPVersion string   `json:"ctgp_version,omitempty"`
+}
+
+// GetPlayerSession returns the QR2 login of the profile, if it has one
+func GetPlayerSession(profileID uint32) (PlayerSessionInfo, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	login, exists := logins[profileID]
+	if !exists {
+		return PlayerSessionInfo{}, false
+	}
+
+	info := PlayerSessionInfo{
+		GameCode:    login.GameCode,
+		InGameName:  login.InGameName,
+		PublicIP:    login.GPPublicIP,
+		Restricted:  login.Restricted,
+		MiiNames:    []string{},
+		OpenHoster:  login.OpenHoster,
+		CTGPVersion: login.CTGPVER,
+	}
+
+	if session := login.session; session != nil {
+		info.HasSession = true
+		info.JoinIndex = session.Data["+joinindex"]
+		if session.groupPointer != nil {
+			info.GroupName = session.groupPointer.GroupName
+		}
+
+		for i := 0; i < 32; i++ {
+			if miiName := session.Data["+mii_name"+strconv.Itoa(i)]; miiName != "" {
+				info.MiiNames = append(info.MiiNames, miiName)
+			}
+		}
+	}
+
+	return info, true
+}
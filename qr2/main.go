@@ -34,14 +34,8 @@ var (
 )
 
 func StartServer(reload bool) {
-	// Get config
-	config := common.GetConfig()
-
-	address := *config.GameSpyAddress + ":27900"
-	conn, err := net.ListenPacket("udp", address)
-	if err != nil {
-		panic(err)
-	}
+	// The frontend owns the socket so heartbeats aren't dropped while the backend reloads
+	conn := common.ListenUDP("qr2")
 
 	masterConn = conn
 	inShutdown = false
@@ -76,7 +70,7 @@ func StartServer(reload bool) {
 
 		// Close the listener when the application closes.
 		defer conn.Close()
-		logging.Notice("QR2", "Listening on", aurora.BrightCyan(address))
+		logging.Notice("QR2", "Receiving packets from the frontend")
 
 		for {
 			if inShutdown {
@@ -172,6 +166,7 @@ func handleConnection(conn net.PacketConn, addr net.UDPAddr, buffer []byte) {
 
 	case HeartbeatRequest:
 		// logging.Info(moduleName, "Command:", aurora.Yellow("HEARTBEAT"))
+		heartbeatCount.Inc()
 		heartbeat(moduleName, conn, addr, buffer)
 
 	case AddErrorRequest:
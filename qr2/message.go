@@ -124,6 +124,8 @@ func SendClientMessage(senderIP string, destSearchID uint64, message []byte) {
 			return
 		}
 
+		clientMessageCount.Inc(common.GetMatchCommandString(message[8]))
+
 		if message[8] == common.MatchReservation {
 			if matchData.Reservation.HasPublicIP {
 				if qr2IP != matchData.Reservation.PublicIP {
@@ -0,0 +1,28 @@
+package qr2
+
+import "wwfc/metrics"
+
+var (
+	heartbeatCount     = metrics.NewCounter("wwfc_qr2_heartbeats_total", "QR2 heartbeat packets received.")
+	clientMessageCount = metrics.NewCounter("wwfc_qr2_client_messages_total", "QR2 client messages forwarded, by match command.", "command")
+)
+
+func init() {
+	metrics.NewGaugeFunc("wwfc_qr2_sessions", "QR2 sessions currently registered.", "", func() map[string]float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return map[string]float64{"": float64(len(sessions))}
+	})
+
+	metrics.NewGaugeFunc("wwfc_qr2_groups", "QR2 groups currently open, by game.", "game", func() map[string]float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		counts := map[string]float64{}
+		for _, group := range groups {
+			counts[group.GameName]++
+		}
+		return counts
+	})
+}
@@ -1,15 +1,14 @@
 package qr2
 
 import (
-	"encoding/gob"
 	"fmt"
 	"math/rand"
 	"net"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 	"wwfc/common"
+	"wwfc/events"
 	"wwfc/logging"
 
 	"github.com/logrusorgru/aurora/v3"
@@ -81,12 +80,20 @@ func (session *Session) removeFromGroup() {
 
 	delete(session.groupPointer.players, session)
 
+	events.Publish(events.PlayerLeft, session.groupPointer.GameName, events.GroupPlayerData{
+		GroupName: session.groupPointer.GroupName,
+		PID:       session.Data["dwc_pid"],
+		JoinIndex: session.Data["+joinindex"],
+	})
+
 	if len(session.groupPointer.players) == 0 {
 		logging.Notice("QR2", "Deleting group", aurora.Cyan(session.groupPointer.GroupName))
 		delete(groups, session.groupPointer.GroupName)
+		events.Publish(events.GroupDeleted, session.groupPointer.GameName, events.GroupData{
+			GroupName: session.groupPointer.GroupName,
+		})
 	} else if session.groupPointer.server == session {
 		logging.Notice("QR2", "Server down in group", aurora.Cyan(session.groupPointer.GroupName))
-		session.groupPointer.server = nil
 		session.groupPointer.findNewServer()
 	}
 
@@ -167,6 +174,14 @@ func setSessionData(moduleName string, addr net.Addr, sessionId uint32, payload
 		}
 	}
 
+	if session.groupPointer != nil && payload["dwc_suspend"] != session.Data["dwc_suspend"] {
+		events.Publish(events.SuspendChanged, session.groupPointer.GameName, events.SuspendData{
+			GroupName: session.groupPointer.GroupName,
+			PID:       payload["dwc_pid"],
+			Suspend:   payload["dwc_suspend"] == "1",
+		})
+	}
+
 	session.Data = payload
 	session.LastKeepAlive = time.Now().Unix()
 	session.SessionID = sessionId
@@ -323,29 +338,18 @@ func GetSearchID(addr uint64) uint64 {
 	return 0
 }
 
-// Save the sessions to a file. Expects the mutex to be locked.
-func saveSessions() error {
-	file, err := os.OpenFile("state/qr2_sessions.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
+func init() {
+	common.RegisterStateSection("qr2_sessions", 1, func() interface{} { return &map[uint64]*Session{} }, nil)
+}
 
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(sessions)
-	file.Close()
-	return err
+// Save the sessions to the state snapshot. Expects the mutex to be locked.
+func saveSessions() error {
+	return common.SaveStateSection("qr2_sessions", sessions)
 }
 
-// Load the sessions from a file. Expects the mutex to be locked.
+// Load the sessions from the state snapshot. Expects the mutex to be locked.
 func loadSessions() error {
-	file, err := os.Open("state/qr2_sessions.gob")
-	if err != nil {
-		return err
-	}
-
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(&sessions)
-	file.Close()
+	err := common.LoadStateSection("qr2_sessions", &sessions)
 	if err != nil {
 		return err
 	}
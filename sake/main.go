@@ -2,37 +2,26 @@ package sake
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"wwfc/common"
+	"wwfc/database"
 	"wwfc/logging"
 
-	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/logrusorgru/aurora/v3"
 )
 
 var (
-	ctx  = context.Background()
-	pool *pgxpool.Pool
+	ctx        = context.Background()
+	friendInfo database.FriendInfoRepository
 )
 
 func StartServer(reload bool) {
-	// Get config
-	config := common.GetConfig()
-
 	common.ReadGameList()
+}
 
-	// Start SQL
-	dbString := fmt.Sprintf("postgres://%s:%s@%s/%s", config.Username, config.Password, config.DatabaseAddress, config.DatabaseName)
-	dbConf, err := pgxpool.ParseConfig(dbString)
-	if err != nil {
-		panic(err)
-	}
-
-	pool, err = pgxpool.ConnectConfig(ctx, dbConf)
-	if err != nil {
-		panic(err)
-	}
+// SetFriendInfoRepository sets the repository used for the friend info storage table
+func SetFriendInfoRepository(repository database.FriendInfoRepository) {
+	friendInfo = repository
 }
 
 func Shutdown() {
@@ -9,7 +9,6 @@ import (
 	"sort"
 	"strconv"
 	"wwfc/common"
-	"wwfc/database"
 	"wwfc/logging"
 
 	"github.com/logrusorgru/aurora/v3"
@@ -248,7 +247,7 @@ func getMyRecords(moduleName string, profileId uint32, gameInfo common.GameInfo,
 		values = map[string]StorageValue{
 			"ownerid":  uintValue(profileId),
 			"recordid": intValue(int32(profileId)),
-			"info":     binaryDataValueBase64(database.GetMKWFriendInfo(pool, ctx, profileId)),
+			"info":     binaryDataValueBase64(friendInfo.GetMKWFriendInfo(ctx, profileId)),
 		}
 	}
 
@@ -292,7 +291,7 @@ func updateRecord(moduleName string, profileId uint32, gameInfo common.GameInfo,
 		}
 
 		// TODO: Validate record data
-		database.UpdateMKWFriendInfo(pool, ctx, profileId, request.Values.RecordFields[0].Value.Value.Value)
+		friendInfo.UpdateMKWFriendInfo(ctx, profileId, request.Values.RecordFields[0].Value.Value.Value)
 		logging.Notice(moduleName, "Updated Mario Kart Wii friend info")
 	}
 
@@ -334,7 +333,7 @@ func searchForRecords(moduleName string, gameInfo common.GameInfo, request Stora
 			{
 				"ownerid":  uintValue(uint32(ownerId)),
 				"recordid": intValue(int32(ownerId)),
-				"info":     binaryDataValueBase64(database.GetMKWFriendInfo(pool, ctx, uint32(ownerId))),
+				"info":     binaryDataValueBase64(friendInfo.GetMKWFriendInfo(ctx, uint32(ownerId))),
 			},
 		}
 	}
@@ -0,0 +1,50 @@
+package sake
+
+import (
+	"encoding/xml"
+	"testing"
+	"wwfc/common"
+	"wwfc/database/databasetest"
+)
+
+func TestMKWFriendInfo(t *testing.T) {
+	friendInfo = databasetest.NewFriendInfoRepository()
+	defer func() { friendInfo = nil }()
+
+	gameInfo := common.GameInfo{Name: "mariokartwii"}
+
+	update := StorageRequestData{TableID: "FriendInfo"}
+	update.Values.RecordFields = []StorageRecordField{{
+		Name: "info",
+		Value: StorageRecordValue{Value: &StorageValue{
+			XMLName: xml.Name{Local: "binaryDataValue"},
+			Value:   "AAECAw==",
+		}},
+	}}
+
+	if response := updateRecord("SAKE", 600000000, gameInfo, update); response.UpdateRecordResult != "Success" {
+		t.Fatal("updateRecord failed:", response.UpdateRecordResult)
+	}
+
+	get := StorageRequestData{TableID: "FriendInfo"}
+	get.Fields.Fields = []string{"ownerid", "info", "unknown"}
+
+	response := getMyRecords("SAKE", 600000000, gameInfo, get)
+	values := response.Values.ArrayOfRecordValue.RecordValues
+	if response.GetMyRecordsResult != "Success" || len(values) != 3 {
+		t.Fatalf("getMyRecords returned %s with %d values", response.GetMyRecordsResult, len(values))
+	}
+
+	if values[0].Value.Value != "600000000" || values[1].Value.Value != "AAECAw==" || values[2].Value != nil {
+		t.Errorf("unexpected values: %v %v %v", values[0].Value, values[1].Value, values[2].Value)
+	}
+
+	search := StorageRequestData{TableID: "FriendInfo", Filter: "ownerid = 600000000", Max: 1}
+	search.Fields.Fields = []string{"info"}
+
+	searchResponse := searchForRecords("SAKE", gameInfo, search)
+	values = searchResponse.Values.ArrayOfRecordValue.RecordValues
+	if searchResponse.SearchForRecordsResult != "Success" || len(values) != 1 || values[0].Value.Value != "AAECAw==" {
+		t.Errorf("searchForRecords returned %s with %v", searchResponse.SearchForRecordsResult, values)
+	}
+}
@@ -26,6 +26,7 @@ func filterServers(moduleName string, servers []map[string]string, queryGame str
 	tree, err := filter.Parse(expression)
 	if err != nil {
 		logging.Error(moduleName, "Error parsing filter:", err.Error())
+		filterErrorCount.Inc()
 		return []map[string]string{}
 	}
 
@@ -47,6 +48,7 @@ func filterServers(moduleName string, servers []map[string]string, queryGame str
 		ret, err := filter.Eval(tree, server, queryGame)
 		if err != nil {
 			logging.Error(moduleName, "Error evaluating filter:", err.Error())
+			filterErrorCount.Inc()
 			return []map[string]string{}
 		}
 		if server["gamename"] == "mariokartwii" {
@@ -2,8 +2,6 @@ package serverbrowser
 
 import (
 	"encoding/binary"
-	"encoding/gob"
-	"os"
 	"wwfc/common"
 	"wwfc/logging"
 
@@ -36,22 +34,17 @@ var (
 	mutex       = deadlock.RWMutex{}
 )
 
+func init() {
+	common.RegisterStateSection("sb_connections", 1, func() interface{} { return &map[uint64]*[]byte{} }, nil)
+}
+
 func StartServer(reload bool) {
 	if !reload {
 		return
 	}
 
 	// Load connection state
-	file, err := os.Open("state/sb_connections.gob")
-	if err != nil {
-		panic(err)
-	}
-
-	decoder := gob.NewDecoder(file)
-
-	err = decoder.Decode(&connBuffers)
-	file.Close()
-
+	err := common.LoadStateSection("sb_connections", &connBuffers)
 	if err != nil {
 		panic(err)
 	}
@@ -61,15 +54,7 @@ func StartServer(reload bool) {
 
 func Shutdown() {
 	// Save connection state
-	file, err := os.OpenFile("state/sb_connections.gob", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	if err != nil {
-		panic(err)
-	}
-
-	encoder := gob.NewEncoder(file)
-
-	err = encoder.Encode(connBuffers)
-	file.Close()
+	err := common.SaveStateSection("sb_connections", connBuffers)
 	if err != nil {
 		panic(err)
 	}
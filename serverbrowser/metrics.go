@@ -0,0 +1,9 @@
+package serverbrowser
+
+import "wwfc/metrics"
+
+var (
+	listRequestCount      = metrics.NewCounter("wwfc_serverbrowser_list_requests_total", "Server list requests, by game.", "game")
+	filterErrorCount      = metrics.NewCounter("wwfc_serverbrowser_filter_errors_total", "Server list filters that failed to parse or evaluate.")
+	matchedServersPerList = metrics.NewHistogram("wwfc_serverbrowser_matched_servers", "Servers returned per filtered server list request.", []float64{0, 1, 2, 5, 10, 20, 50, 100})
+)
@@ -127,6 +127,8 @@ func handleServerListRequest(moduleName string, connIndex uint64, address string
 		return
 	}
 
+	listRequestCount.Inc(gameName)
+
 	var output []byte
 	for _, s := range strings.Split(strings.Split(address, ":")[0], ".") {
 		val, err := strconv.Atoi(s)
@@ -180,6 +182,8 @@ func handleServerListRequest(moduleName string, connIndex uint64, address string
 		} else {
 			servers = filterServers(moduleName, qr2.GetSessionServers(), queryGame, filter, callerPublicIP)
 		}
+
+		matchedServersPerList.Observe(float64(len(servers)))
 	}
 
 	for _, server := range servers {
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"wwfc/common"
+	"wwfc/logging"
+)
+
+// stateMain runs the "state inspect [path]" subcommand
+func stateMain(args []string) {
+	if len(args) == 0 || args[0] != "inspect" {
+		logging.Error("STATE", "Usage: wwfc state inspect [path]")
+		os.Exit(1)
+	}
+
+	path := common.StateSnapshotPath
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	info, err := common.InspectStateSnapshot(path)
+	if err != nil {
+		logging.Error("STATE", "Failed to read", path+":", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(info); err != nil {
+		logging.Error("STATE", err)
+		os.Exit(1)
+	}
+}